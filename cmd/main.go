@@ -1,16 +1,24 @@
 package main
 
 import (
+	"fmt"
 	"github.com/sirupsen/logrus"
 	"html/template"
 	"int-status/internal"
+	"int-status/internal/api"
 	"int-status/internal/cache"
 	"int-status/internal/config"
 	"int-status/internal/manager"
+	"int-status/internal/metrics"
+	"int-status/internal/notifier"
 	"int-status/internal/storage"
+	"io"
+	"math"
 	"net/http"
 	"os"
+	"os/signal"
 	"strings"
+	"syscall"
 	"time"
 	_ "time/tzdata"
 )
@@ -66,6 +74,46 @@ const htmlTemplate = `
             color: rgba(255, 255, 255, 0.5);
             font-size: 0.9em;
         }
+        .incident-cause {
+            color: rgba(255, 255, 255, 0.7);
+            font-size: 0.9em;
+        }
+        .incident-update {
+            border-left: 2px solid rgba(255, 255, 255, 0.1);
+            padding: 4px 12px;
+            margin-top: 8px;
+        }
+        .incident-stage {
+            text-transform: capitalize;
+            font-weight: 500;
+        }
+        .maintenance-card {
+            background-color: rgba(33, 150, 243, 0.05);
+            border: 1px solid rgba(33, 150, 243, 0.2);
+            border-radius: 12px;
+            padding: 16px 20px;
+            margin-bottom: 12px;
+        }
+        .windows {
+            display: flex;
+            justify-content: center;
+            gap: 16px;
+            margin-bottom: 30px;
+        }
+        .window-link {
+            color: rgba(255, 255, 255, 0.5);
+            text-decoration: none;
+        }
+        .timezone {
+            text-align: center;
+            color: rgba(255, 255, 255, 0.5);
+            font-size: 0.9em;
+            margin: -20px 0 30px;
+        }
+        .window-active {
+            color: white;
+            border-bottom: 1px solid #2196F3;
+        }
         .no-incidents {
             text-align: center;
             padding: 30px;
@@ -137,6 +185,8 @@ const htmlTemplate = `
         }
         .status-dots {
             display: flex;
+            flex-wrap: wrap;
+            justify-content: flex-end;
             gap: 6px;
             align-items: center;
         }
@@ -168,12 +218,74 @@ const htmlTemplate = `
         .dot-down {
             background-color: #f44336;
         }
+        .dot-degraded {
+            background-color: #FF9800;
+        }
+        .dot-maintenance {
+            background-color: #2196F3;
+        }
+        .dot-unknown {
+            background-color: #9E9E9E;
+        }
+        .dot-flapping {
+            background-color: #9C27B0;
+        }
         .status-up {
             color: #4CAF50;
         }
+        .status-degraded {
+            color: #FF9800;
+        }
+        .status-maintenance {
+            color: #2196F3;
+        }
+        .status-unknown {
+            color: #9E9E9E;
+        }
+        .status-flapping {
+            color: #9C27B0;
+        }
         .status-down {
             color: #f44336;
         }
+        .uptime {
+            display: flex;
+            justify-content: space-between;
+            margin-top: 8px;
+            font-size: 0.9em;
+        }
+        .uptime-window {
+            display: flex;
+            flex-direction: column;
+            align-items: center;
+        }
+        .uptime-label {
+            color: rgba(255, 255, 255, 0.5);
+        }
+        .uptime-breached {
+            color: #f44336;
+        }
+        .sla-text {
+            color: rgba(255, 255, 255, 0.5);
+            font-size: 0.9em;
+        }
+        .slo-burning {
+            color: #f44336;
+        }
+        .components {
+            margin-top: 8px;
+            color: rgba(255, 255, 255, 0.7);
+            font-size: 0.9em;
+        }
+        .components summary {
+            cursor: pointer;
+        }
+        .component-row {
+            display: flex;
+            justify-content: space-between;
+            align-items: center;
+            padding: 4px 0;
+        }
 
         @media (max-width: 1024px) {
             .dashboard {
@@ -209,22 +321,71 @@ const htmlTemplate = `
 	</h1>
     <!-- Incidents 섹션 -->
     <div class="incidents-section">
-        <h2 class="incidents-title">Today's Outages</h2>
-        {{if .Incidents}}
+        <div class="windows">
+            {{range .Windows}}
+            <a class="window-link {{if eq .Key $.Window.Key}}window-active{{end}}" href="?window={{.Key}}">{{.Label}}</a>
+            {{end}}
+        </div>
+        <div class="timezone">
+            Times in {{.Location}} · <a id="local-timezone" class="window-link" href="#">use my time zone</a>
+        </div>
+        {{if .Maintenance}}
+        <h2 class="incidents-title">Scheduled Maintenance</h2>
+        {{range .Maintenance}}
+        <div class="maintenance-card">
+            <div class="incident-header">
+                <div class="incident-service">{{.Title}}</div>
+                {{if .Active $.Now}}
+                <div class="incident-time">In progress until {{formatTime $.Location .End}}</div>
+                {{else}}
+                <div class="incident-time">{{formatTime $.Location .Start}} - {{formatTime $.Location .End}}</div>
+                {{end}}
+            </div>
+            <div class="incident-cause">Affects {{join .Services ", "}}</div>
+        </div>
+        {{end}}
+        {{end}}
+        <h2 class="incidents-title">{{.Window.Heading}}</h2>
+        {{if or .Incidents .ManualIncidents}}
+            {{range .ManualIncidents}}
+            <div class="incident-card">
+                <div class="incident-header">
+                    <div class="incident-service">{{.Title}}</div>
+                    {{if .Ongoing}}
+                    <div class="incident-time"><span class="incident-stage">{{.Status}}</span> since {{formatTime $.Location .StartTime}}</div>
+                    {{else}}
+                    <div class="incident-time"><span class="incident-stage">{{.Status}}</span>: {{formatTime $.Location .StartTime}} - {{formatTime $.Location .EndTime}}</div>
+                    {{end}}
+                </div>
+                <div class="incident-cause">Affects {{join .Services ", "}}</div>
+                {{range .Updates}}
+                <div class="incident-update">
+                    <span class="incident-stage">{{.Status}}</span>
+                    <span class="incident-time">{{formatTime $.Location .Timestamp}}</span>
+                    <div>{{.Message}}</div>
+                </div>
+                {{end}}
+            </div>
+            {{end}}
             {{range $service, $serviceIncidents := .Incidents}}
                 {{range $incident := $serviceIncidents}}
                 <div class="incident-card">
                     <div class="incident-header">
                         <div class="incident-service">{{$service}}</div>
-                        <div class="incident-time">Down: {{formatTime $incident.StartTime}} - {{formatTime $incident.EndTime}}</div>
+                        {{if $incident.Ongoing}}
+                        <div class="incident-time">Down since {{formatTime $.Location $incident.StartTime}} (ongoing)</div>
+                        {{else}}
+                        <div class="incident-time">Down: {{formatTime $.Location $incident.StartTime}} - {{formatTime $.Location $incident.EndTime}}</div>
+                        {{end}}
                     </div>
+                    {{with $incident.Cause}}<div class="incident-cause">{{.}}</div>{{end}}
                 </div>
                 {{end}}
             {{end}}
         {{else}}
             <div class="no-incidents">
                 <div class="perfect-day">Perfect Day!</div>
-                <div class="sub-message">All systems have been operational today</div>
+                <div class="sub-message">All systems have been operational {{.Window.Phrase}}</div>
             </div>
         {{end}}
     </div>
@@ -233,27 +394,64 @@ const htmlTemplate = `
 
     <!-- Services 섹션 -->
     <div class="dashboard">
-        {{range $service, $statuses := .Services}}
+        {{range $service, $history := .Services}}
         <div class="service-card">
             <div class="service-name">{{$service}}</div>
             <div class="service-status">
                 <div class="status-info">
-                    <div class="status-text {{if eq (index $statuses (sub (len $statuses) 1)).Status "UP"}}status-up{{else}}status-down{{end}}">
-                        {{if eq (index $statuses (sub (len $statuses) 1)).Status "UP"}}Operational{{else}}Down{{end}}
+                    <div class="status-text {{statusClass "status" $history.Latest.State}}">
+                        {{statusLabel $history.Latest.State}}
                     </div>
-                    <div class="latency-text">{{(index $statuses (sub (len $statuses) 1)).Latency}} ms</div>
+                    <div class="latency-text">{{$history.Latest.Latency}} ms</div>
+                    {{with $history.Latest.Message}}<div class="latency-text">{{.}}</div>{{end}}
+                    {{with $history.Latest.Certificate}}<div class="latency-text">TLS: {{.DaysLeft}} days left · {{.Issuer}}</div>{{end}}
                 </div>
                 <div class="status-dots">
-                    {{range $statuses}}
-                    <div class="dot {{if eq .Status "UP"}}dot-up{{else}}dot-down{{end}}" 
-                         data-timestamp="{{formatTime .Timestamp}}">
+                    {{range $history.History}}
+                    <div class="dot {{statusClass "dot" .State}}" 
+                         data-timestamp="{{formatTime $.Location .Timestamp}}">
                     </div>
                     {{end}}
                 </div>
             </div>
+            {{with index $.Uptime $service}}
+            <div class="uptime">
+                {{range .}}
+                <div class="uptime-window{{if .Breached}} uptime-breached{{end}}">
+                    <span class="uptime-label">{{.Window.Key}}</span>
+                    <span>{{if .HasData}}{{formatPercent .Percentage}}{{else}}-{{end}}</span>
+                </div>
+                {{end}}
+            </div>
+            {{with (index . 0).Target}}<div class="sla-text">SLA {{formatPercent .}}</div>{{end}}
+            {{end}}
+            {{with index $.SLO $service}}
+            <div class="sla-text{{if or .Burning (lt .BudgetRemaining 0.0)}} slo-burning{{end}}">
+                SLO {{formatPercent .Objective.Target}} · {{formatBudget .BudgetRemaining}}{{if .Burning}} · burning fast{{end}}
+            </div>
+            {{end}}
+            {{with $history.Latest.Components}}
+            <details class="components">
+                <summary>Components</summary>
+                {{range .}}
+                <div class="component-row">
+                    <span>{{.Name}}</span>
+                    <div class="dot {{statusClass "dot" .Status}}"></div>
+                </div>
+                {{end}}
+            </details>
+            {{end}}
         </div>
         {{end}}
     </div>
+    <script>
+        document.getElementById("local-timezone").addEventListener("click", function(event) {
+            event.preventDefault();
+            var params = new URLSearchParams(window.location.search);
+            params.set("tz", Intl.DateTimeFormat().resolvedOptions().timeZone);
+            window.location.search = params.toString();
+        });
+    </script>
 </body>
 </html>
 `
@@ -332,46 +530,189 @@ const errorTemplate = `
 </html>
 `
 
+// timeZoneLoc is the configured time zone; viewers can override it per request.
 var timeZoneLoc *time.Location
 
 var funcMap = template.FuncMap{
 	"sub": func(a, b int) int {
 		return a - b
 	},
-	"formatTime": func(t time.Time) string {
-		kstTime := t.In(timeZoneLoc)
-		return kstTime.Format("2006-01-02 15:04:05")
+	"formatTime": func(location *time.Location, t time.Time) string {
+		return t.In(location).Format("2006-01-02 15:04:05")
+	},
+	"join": strings.Join,
+	// formatPercent truncates rather than rounds, so that 99.996% is not shown
+	// as a perfect 100.00%.
+	"formatPercent": func(percent float64) string {
+		return fmt.Sprintf("%.2f%%", math.Floor(percent*100)/100)
+	},
+	"formatBudget": func(remaining float64) string {
+		if remaining < 0 {
+			return "error budget exhausted"
+		}
+		return fmt.Sprintf("%.2f%% of error budget left", math.Floor(remaining*10000)/100)
+	},
+	"statusClass": func(prefix string, status internal.State) string {
+		switch status {
+		case internal.StateUp:
+			return prefix + "-up"
+		case internal.StateDegraded:
+			return prefix + "-degraded"
+		case internal.StateMaintenance:
+			return prefix + "-maintenance"
+		case internal.StateUnknown:
+			return prefix + "-unknown"
+		case internal.StateFlapping:
+			return prefix + "-flapping"
+		default:
+			return prefix + "-down"
+		}
+	},
+	"statusLabel": func(status internal.State) string {
+		switch status {
+		case internal.StateUp:
+			return "Operational"
+		case internal.StateDegraded:
+			return "Degraded"
+		case internal.StateMaintenance:
+			return "Maintenance"
+		case internal.StateUnknown:
+			return "Unknown"
+		case internal.StateFlapping:
+			return "Flapping"
+		default:
+			return "Down"
+		}
 	},
 }
 
 type DashboardData struct {
-	Services  map[string][]internal.Status
-	Incidents map[string][]internal.Incident
+	Location        *time.Location
+	Window          Window
+	Windows         []Window
+	Services        map[string]manager.ServiceHistory
+	Incidents       map[string][]internal.Incident
+	ManualIncidents []internal.ManualIncident
+	Maintenance     []internal.Maintenance
+	Uptime          map[string][]manager.ServiceUptime
+	SLO             map[string]*internal.SLOReport
+	Now             time.Time
 }
 
-func main() {
-	location, err := time.LoadLocation("Asia/Seoul")
-	if err != nil {
-		logrus.Fatalf("Error loading KST timezone: %v", err)
+// dashboardMaintenance is how far ahead the dashboard lists maintenance.
+const dashboardMaintenance = 7 * 24 * time.Hour
+
+// dashboardDots is the maximum number of status dots per service.
+const dashboardDots = 30
+
+// Window is a time range that can be selected on the dashboard.
+type Window struct {
+	Key     string
+	Label   string
+	Heading string
+	Phrase  string
+	Range   func(now time.Time, location *time.Location) (time.Time, time.Time)
+}
+
+var windows = []Window{
+	{"1h", "1h", "Outages in the Last Hour", "in the last hour", func(now time.Time, location *time.Location) (time.Time, time.Time) {
+		return now.Add(-time.Hour), now
+	}},
+	{"today", "Today", "Today's Outages", "today", func(now time.Time, location *time.Location) (time.Time, time.Time) {
+		start := startOfDay(now, location)
+		return start, start.AddDate(0, 0, 1).Add(-time.Nanosecond)
+	}},
+	{"yesterday", "Yesterday", "Yesterday's Outages", "yesterday", func(now time.Time, location *time.Location) (time.Time, time.Time) {
+		end := startOfDay(now, location)
+		return end.AddDate(0, 0, -1), end.Add(-time.Nanosecond)
+	}},
+	{"24h", "24h", "Outages in the Last 24 Hours", "in the last 24 hours", func(now time.Time, location *time.Location) (time.Time, time.Time) {
+		return now.Add(-24 * time.Hour), now
+	}},
+	{"7d", "7d", "Outages in the Last 7 Days", "in the last 7 days", func(now time.Time, location *time.Location) (time.Time, time.Time) {
+		return now.AddDate(0, 0, -7), now
+	}},
+}
+
+// findWindow returns the window selected by key, defaulting to today.
+func findWindow(key string) Window {
+	for _, window := range windows {
+		if window.Key == key {
+			return window
+		}
 	}
-	timeZoneLoc = location
+	return windows[1]
+}
+
+func startOfDay(t time.Time, location *time.Location) time.Time {
+	t = t.In(location)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, location)
+}
 
+// timezoneCookie remembers a viewer's time zone override.
+const timezoneCookie = "tz"
+
+// viewerLocation returns the time zone to render a request in: the "tz" query
+// parameter, the X-Timezone header, the "tz" cookie, or the configured zone.
+func viewerLocation(w http.ResponseWriter, r *http.Request) *time.Location {
+	if name := r.URL.Query().Get("tz"); name != "" {
+		if location, err := time.LoadLocation(name); err == nil {
+			http.SetCookie(w, &http.Cookie{Name: timezoneCookie, Value: name, Path: "/", MaxAge: 365 * 24 * 60 * 60})
+			return location
+		}
+	}
+	if name := r.Header.Get("X-Timezone"); name != "" {
+		if location, err := time.LoadLocation(name); err == nil {
+			return location
+		}
+	}
+	if cookie, err := r.Cookie(timezoneCookie); err == nil {
+		if location, err := time.LoadLocation(cookie.Value); err == nil {
+			return location
+		}
+	}
+	return timeZoneLoc
+}
+
+func main() {
 	const yamlPath = "./config/config.yaml"
-	serviceConfigs, err := config.LoadServices(yamlPath)
+	conf, err := config.Load(yamlPath)
 	if err != nil {
 		logrus.Fatalf("Error loading services: %v", err)
 	}
 
-	region := GetEnv("AWS_REGION")
-	tableName := GetEnv("DYNAMODB_TABLE_NAME")
-	dbStorage, err := storage.NewDynamoDBStorage(region, tableName)
+	location, err := time.LoadLocation(conf.Timezone)
+	if err != nil {
+		logrus.Fatalf("Error loading timezone %s: %v", conf.Timezone, err)
+	}
+	timeZoneLoc = location
+
+	dbStorage, err := storage.Open(conf.Storage)
 	if err != nil {
 		logrus.Fatal(err)
 	}
+	logrus.Infof("Using %s storage", conf.Storage.Backend)
 
-	serviceManager := manager.NewServiceManager(serviceConfigs, dbStorage)
+	serviceManager, err := manager.NewServiceManager(conf.Services, dbStorage)
+	if err != nil {
+		logrus.Fatal(err)
+	}
 	htmlCache := cache.NewHTMLCache(10 * time.Second)
 
+	dispatcher, err := notifier.NewDispatcher(conf)
+	if err != nil {
+		logrus.Fatal(err)
+	}
+	serviceManager.SetDispatcher(dispatcher)
+
+	if err := serviceManager.SetMaintenance(conf.Maintenance, location); err != nil {
+		logrus.Fatal(err)
+	}
+
+	checkMetrics := metrics.New()
+	serviceManager.AddListener(checkMetrics.Observe)
+	checkMetrics.SetSLOReports(serviceManager.GetSLOReports)
+
 	go func() {
 		dashboardTmpl := template.Must(template.New("dashboard").Funcs(funcMap).Parse(htmlTemplate))
 		errorTmpl := template.Must(template.New("error").Parse(errorTemplate))
@@ -380,14 +721,31 @@ func main() {
 			w.WriteHeader(http.StatusOK)
 		})
 
+		http.Handle("/metrics", checkMetrics.Handler())
+		http.Handle("/api/v1/", api.NewHandler(serviceManager))
+		http.Handle("/api/v2/", api.NewStatuspageHandler(serviceManager, conf.Page, conf.Timezone))
+		if conf.Admin.Password != "" {
+			admin := api.NewAdminHandler(serviceManager, conf.Admin, location)
+			http.Handle("/admin", admin)
+			http.Handle("/admin/", admin)
+			http.Handle("/api/v1/admin/", admin)
+		} else {
+			logrus.Info("Admin API disabled; set admin.password to enable it")
+		}
+
 		http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-			if content, ok := htmlCache.Get(); ok {
+			window := findWindow(r.URL.Query().Get("window"))
+			location := viewerLocation(w, r)
+			cacheKey := window.Key + "|" + location.String()
+			if content, ok := htmlCache.Get(cacheKey); ok {
 				w.Header().Set("Content-Type", "text/html")
 				w.Write([]byte(content))
 				return
 			}
 
-			statuses, err := serviceManager.GetDailyServiceStatus()
+			now := time.Now()
+			from, to := window.Range(now, location)
+			statuses, err := serviceManager.GetServiceStatus(from, to, dashboardDots)
 			if err != nil {
 				logrus.Errorf("Error getting service statuses: %v", err)
 				w.Header().Set("Content-Type", "text/html")
@@ -395,14 +753,40 @@ func main() {
 				return
 			}
 
-			incidents, err := serviceManager.GetDailyIncidents()
+			incidents, err := serviceManager.GetIncidents(from, to)
 			if err != nil {
 				logrus.Errorf("Error getting service incidents: %v", err)
 			}
+			manualIncidents, err := serviceManager.GetManualIncidents(from, to)
+			if err != nil {
+				logrus.Errorf("Error getting manual incidents: %v", err)
+			}
+
+			uptime := make(map[string][]manager.ServiceUptime)
+			for _, conf := range serviceManager.Services() {
+				windows, err := serviceManager.GetUptime(conf.Name)
+				if err != nil {
+					logrus.Errorf("Error getting uptime of %s: %v", conf.Name, err)
+					continue
+				}
+				uptime[conf.Name] = windows
+			}
+			slo := make(map[string]*internal.SLOReport)
+			for _, report := range serviceManager.GetSLOReports() {
+				slo[report.Service] = &report
+			}
 
 			data := DashboardData{
-				Services:  statuses,
-				Incidents: incidents,
+				Location:        location,
+				Window:          window,
+				Windows:         windows,
+				Services:        statuses,
+				Incidents:       incidents,
+				ManualIncidents: manualIncidents,
+				Maintenance:     serviceManager.GetMaintenance(now, now.Add(dashboardMaintenance)),
+				Uptime:          uptime,
+				SLO:             slo,
+				Now:             now,
 			}
 
 			var buf strings.Builder
@@ -413,7 +797,7 @@ func main() {
 			}
 
 			rendered := buf.String()
-			htmlCache.Set(rendered)
+			htmlCache.Set(cacheKey, rendered)
 
 			w.Header().Set("Content-Type", "text/html")
 			w.Write([]byte(rendered))
@@ -423,9 +807,18 @@ func main() {
 		logrus.Fatal(http.ListenAndServe(":8080", nil))
 	}()
 
-	serviceManager.StartMonitoring(1 * time.Minute)
-}
+	go serviceManager.StartMonitoring(conf.Defaults.Interval)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	sig := <-signals
+	logrus.Infof("Received %s, shutting down", sig)
 
-func GetEnv(key string) string {
-	return os.Getenv(key)
+	serviceManager.Stop()
+	dispatcher.Wait()
+	if closer, ok := dbStorage.(io.Closer); ok {
+		if err := closer.Close(); err != nil {
+			logrus.Errorf("Error closing storage: %v", err)
+		}
+	}
 }
@@ -0,0 +1,489 @@
+package api
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"html/template"
+	"int-status/internal"
+	"int-status/internal/manager"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// adminIncidentRange is how far back the admin form lists incidents.
+const adminIncidentRange = 7 * 24 * time.Hour
+
+const adminTemplate = `
+<!DOCTYPE html>
+<html>
+<head>
+    <title>TINY PING - Admin</title>
+    <style>
+        body {
+            background-color: #1a1a1a;
+            color: white;
+            font-family: -apple-system, BlinkMacSystemFont, "Segoe UI", Roboto, Arial, sans-serif;
+            margin: 0;
+            padding: 20px;
+        }
+        h1 {
+            text-align: center;
+            font-size: 2.5em;
+            margin-bottom: 40px;
+            font-weight: normal;
+        }
+        h2 {
+            font-size: 1.4em;
+            font-weight: 500;
+        }
+        .admin {
+            max-width: 800px;
+            margin: 0 auto;
+        }
+        .card {
+            background-color: rgba(255, 255, 255, 0.05);
+            border-radius: 12px;
+            padding: 20px;
+            margin-bottom: 12px;
+        }
+        .error {
+            background-color: rgba(244, 67, 54, 0.1);
+            border: 1px solid rgba(244, 67, 54, 0.3);
+            border-radius: 12px;
+            padding: 12px 20px;
+            margin-bottom: 20px;
+        }
+        .header {
+            display: flex;
+            justify-content: space-between;
+            align-items: center;
+        }
+        .title {
+            font-size: 1.2em;
+        }
+        .muted {
+            color: rgba(255, 255, 255, 0.5);
+            font-size: 0.9em;
+        }
+        .update {
+            border-left: 2px solid rgba(255, 255, 255, 0.1);
+            padding: 4px 12px;
+            margin: 8px 0;
+        }
+        .stage {
+            text-transform: capitalize;
+            font-weight: 500;
+        }
+        label {
+            display: block;
+            margin: 12px 0 4px;
+        }
+        .services label {
+            display: inline-block;
+            margin-right: 16px;
+        }
+        input[type=text], select, textarea {
+            width: 100%;
+            box-sizing: border-box;
+            background-color: rgba(255, 255, 255, 0.08);
+            border: 1px solid rgba(255, 255, 255, 0.15);
+            border-radius: 6px;
+            color: white;
+            padding: 8px;
+            font: inherit;
+        }
+        button {
+            margin-top: 12px;
+            background-color: #2196F3;
+            border: none;
+            border-radius: 6px;
+            color: white;
+            padding: 8px 16px;
+            font: inherit;
+            cursor: pointer;
+        }
+    </style>
+</head>
+<body>
+    <h1>TINY PING ADMIN</h1>
+    <div class="admin">
+        {{with .Error}}<div class="error">{{.}}</div>{{end}}
+
+        <h2>New Incident</h2>
+        <form class="card" method="post" action="/admin/incidents">
+            <label for="title">Title</label>
+            <input type="text" id="title" name="title" required>
+            <label>Affected services</label>
+            <div class="services">
+                {{range .Services}}<label><input type="checkbox" name="services" value="{{.}}"> {{.}}</label>{{end}}
+            </div>
+            <label for="status">Status</label>
+            <select id="status" name="status">
+                {{range .Statuses}}<option value="{{.}}">{{.}}</option>{{end}}
+            </select>
+            <label for="message">Message</label>
+            <textarea id="message" name="message" rows="3" required></textarea>
+            <button type="submit">Create incident</button>
+        </form>
+
+        <h2>Incidents of the Last 7 Days</h2>
+        {{range .Incidents}}
+        <div class="card">
+            <div class="header">
+                <div class="title">{{.Title}}</div>
+                <div class="stage">{{.Status}}</div>
+            </div>
+            <div class="muted">{{join .Services ", "}}</div>
+            {{range .Updates}}
+            <div class="update">
+                <span class="stage">{{.Status}}</span>
+                <span class="muted">{{formatTime $.Location .Timestamp}}</span>
+                <div>{{.Message}}</div>
+            </div>
+            {{end}}
+            {{if .Ongoing}}
+            <form method="post" action="/admin/incidents/{{.ID}}/updates">
+                <label>Status</label>
+                <select name="status">
+                    {{$current := .Status}}
+                    {{range $.Statuses}}<option value="{{.}}"{{if eq . $current}} selected{{end}}>{{.}}</option>{{end}}
+                </select>
+                <label>Message</label>
+                <textarea name="message" rows="2" required></textarea>
+                <button type="submit">Post update</button>
+            </form>
+            {{end}}
+        </div>
+        {{else}}
+        <div class="card muted">No incidents have been posted in the last 7 days.</div>
+        {{end}}
+    </div>
+</body>
+</html>
+`
+
+// adminData is what the admin form is rendered with.
+// @field Location  The time zone of displayed times.
+// @field Services  The names of every configured service.
+// @field Statuses  The stages an incident can be in.
+// @field Incidents The manual incidents of the last 7 days, newest first.
+// @field Error     A problem with the last submission, if any.
+type adminData struct {
+	Location  *time.Location
+	Services  []string
+	Statuses  []internal.IncidentStatus
+	Incidents []internal.ManualIncident
+	Error     string
+}
+
+// createIncidentRequest is the body of POST /api/v1/admin/incidents.
+type createIncidentRequest struct {
+	Title    string                  `json:"title"`
+	Services []string                `json:"services"`
+	Status   internal.IncidentStatus `json:"status"`
+	Message  string                  `json:"message"`
+}
+
+// incidentUpdateRequest is the body of POST /api/v1/admin/incidents/{id}/updates.
+type incidentUpdateRequest struct {
+	Status  internal.IncidentStatus `json:"status"`
+	Message string                  `json:"message"`
+}
+
+// createMaintenanceRequest is the body of POST /api/v1/admin/maintenance. A
+// window has either start and end, or a schedule and a duration such as "2h".
+type createMaintenanceRequest struct {
+	Title    string    `json:"title"`
+	Services []string  `json:"services"`
+	Groups   []string  `json:"groups"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	Schedule string    `json:"schedule"`
+	Duration string    `json:"duration"`
+}
+
+// AdminHandler serves the admin API and the admin form, which operators use to
+// post incidents that checks cannot detect and to schedule maintenance. Every route requires the
+// credentials of AdminConf.
+type AdminHandler struct {
+	manager  *manager.ServiceManager
+	conf     internal.AdminConf
+	location *time.Location
+	form     *template.Template
+	mux      *http.ServeMux
+}
+
+// NewAdminHandler creates the admin handler and registers its routes. Times
+// on the form are shown in location.
+func NewAdminHandler(serviceManager *manager.ServiceManager, conf internal.AdminConf, location *time.Location) *AdminHandler {
+	h := &AdminHandler{
+		manager:  serviceManager,
+		conf:     conf,
+		location: location,
+		mux:      http.NewServeMux(),
+	}
+	h.form = template.Must(template.New("admin").Funcs(template.FuncMap{
+		"formatTime": func(location *time.Location, t time.Time) string {
+			return t.In(location).Format("2006-01-02 15:04:05")
+		},
+		"join": strings.Join,
+	}).Parse(adminTemplate))
+
+	h.mux.HandleFunc("GET /admin", h.showForm)
+	h.mux.HandleFunc("POST /admin/incidents", h.submitIncident)
+	h.mux.HandleFunc("POST /admin/incidents/{id}/updates", h.submitUpdate)
+	h.mux.HandleFunc("POST /api/v1/admin/incidents", h.createIncident)
+	h.mux.HandleFunc("POST /api/v1/admin/incidents/{id}/updates", h.postUpdate)
+	h.mux.HandleFunc("GET /api/v1/admin/maintenance", h.listMaintenanceWindows)
+	h.mux.HandleFunc("POST /api/v1/admin/maintenance", h.createMaintenanceWindow)
+	h.mux.HandleFunc("DELETE /api/v1/admin/maintenance/{id}", h.deleteMaintenanceWindow)
+
+	return h
+}
+
+func (h *AdminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !h.authorized(r) {
+		w.Header().Set("WWW-Authenticate", `Basic realm="TinyPing admin", charset="UTF-8"`)
+		writeError(w, http.StatusUnauthorized, errors.New("unauthorized"))
+		return
+	}
+	if r.Method != http.MethodGet && r.Method != http.MethodHead && h.crossOrigin(r) {
+		writeError(w, http.StatusForbidden, errors.New("cross-origin request rejected"))
+		return
+	}
+	h.mux.ServeHTTP(w, r)
+}
+
+// POST /api/v1/admin/incidents
+func (h *AdminHandler) createIncident(w http.ResponseWriter, r *http.Request) {
+	var request createIncidentRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %v", err))
+		return
+	}
+
+	incident, err := h.manager.CreateIncident(request.Title, request.Services, internal.IncidentUpdate{
+		Status:  request.Status,
+		Message: request.Message,
+	})
+	if err != nil {
+		writeError(w, incidentErrorCode(err), err)
+		return
+	}
+	logrus.Infof("Created incident %s: %s", incident.ID, incident.Title)
+	writeJSON(w, http.StatusCreated, newManualIncidentJSON(incident))
+}
+
+// POST /api/v1/admin/incidents/{id}/updates
+func (h *AdminHandler) postUpdate(w http.ResponseWriter, r *http.Request) {
+	var request incidentUpdateRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %v", err))
+		return
+	}
+
+	incident, err := h.manager.PostIncidentUpdate(r.PathValue("id"), internal.IncidentUpdate{
+		Status:  request.Status,
+		Message: request.Message,
+	})
+	if err != nil {
+		writeError(w, incidentErrorCode(err), err)
+		return
+	}
+	logrus.Infof("Posted %s update on incident %s", incident.Status(), incident.ID)
+	writeJSON(w, http.StatusOK, newManualIncidentJSON(incident))
+}
+
+// GET /api/v1/admin/maintenance
+func (h *AdminHandler) listMaintenanceWindows(w http.ResponseWriter, r *http.Request) {
+	windows := make([]maintenanceWindowJSON, 0)
+	for _, window := range h.manager.MaintenanceWindows() {
+		windows = append(windows, newMaintenanceWindowJSON(window))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"windows": windows,
+	})
+}
+
+// POST /api/v1/admin/maintenance
+func (h *AdminHandler) createMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	var request createMaintenanceRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("invalid request body: %v", err))
+		return
+	}
+
+	window := internal.MaintenanceWindow{
+		Title:    request.Title,
+		Services: request.Services,
+		Groups:   request.Groups,
+		Start:    request.Start,
+		End:      request.End,
+		Schedule: request.Schedule,
+	}
+	if request.Duration != "" {
+		duration, err := time.ParseDuration(request.Duration)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid duration: %v", err))
+			return
+		}
+		window.Duration = duration
+	}
+
+	window, err := h.manager.AddMaintenanceWindow(window)
+	if err != nil {
+		writeError(w, maintenanceErrorCode(err), err)
+		return
+	}
+	logrus.Infof("Created maintenance window %s: %s", window.ID, window.Title)
+	writeJSON(w, http.StatusCreated, newMaintenanceWindowJSON(window))
+}
+
+// DELETE /api/v1/admin/maintenance/{id}
+func (h *AdminHandler) deleteMaintenanceWindow(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	if err := h.manager.DeleteMaintenanceWindow(id); err != nil {
+		writeError(w, maintenanceErrorCode(err), err)
+		return
+	}
+	logrus.Infof("Deleted maintenance window %s", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// GET /admin
+func (h *AdminHandler) showForm(w http.ResponseWriter, r *http.Request) {
+	h.renderForm(w, http.StatusOK, "")
+}
+
+// POST /admin/incidents
+func (h *AdminHandler) submitIncident(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		h.renderForm(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	incident, err := h.manager.CreateIncident(r.PostForm.Get("title"), r.PostForm["services"], internal.IncidentUpdate{
+		Status:  internal.IncidentStatus(r.PostForm.Get("status")),
+		Message: r.PostForm.Get("message"),
+	})
+	if err != nil {
+		h.renderForm(w, incidentErrorCode(err), err.Error())
+		return
+	}
+	logrus.Infof("Created incident %s: %s", incident.ID, incident.Title)
+	http.Redirect(w, r, "/admin", http.StatusSeeOther)
+}
+
+// POST /admin/incidents/{id}/updates
+func (h *AdminHandler) submitUpdate(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		h.renderForm(w, http.StatusBadRequest, err.Error())
+		return
+	}
+
+	incident, err := h.manager.PostIncidentUpdate(r.PathValue("id"), internal.IncidentUpdate{
+		Status:  internal.IncidentStatus(r.PostForm.Get("status")),
+		Message: r.PostForm.Get("message"),
+	})
+	if err != nil {
+		h.renderForm(w, incidentErrorCode(err), err.Error())
+		return
+	}
+	logrus.Infof("Posted %s update on incident %s", incident.Status(), incident.ID)
+	http.Redirect(w, r, "/admin", http.StatusSeeOther)
+}
+
+func (h *AdminHandler) renderForm(w http.ResponseWriter, code int, message string) {
+	to := time.Now()
+	incidents, err := h.manager.GetManualIncidents(to.Add(-adminIncidentRange), to)
+	if err != nil {
+		logrus.Errorf("Error getting manual incidents: %v", err)
+		if message == "" {
+			message = "Incidents could not be loaded."
+		}
+	}
+
+	data := adminData{
+		Location: h.location,
+		Statuses: internal.IncidentStatuses,
+		Error:    message,
+	}
+	for _, conf := range h.manager.Services() {
+		data.Services = append(data.Services, conf.Name)
+	}
+	for i := len(incidents) - 1; i >= 0; i-- {
+		data.Incidents = append(data.Incidents, incidents[i])
+	}
+
+	w.Header().Set("Content-Type", "text/html")
+	w.WriteHeader(code)
+	if err := h.form.Execute(w, data); err != nil {
+		logrus.Errorf("Error executing admin template: %v", err)
+	}
+}
+
+// authorized checks the Basic credentials of r in constant time.
+func (h *AdminHandler) authorized(r *http.Request) bool {
+	username, password, ok := r.BasicAuth()
+	if !ok {
+		return false
+	}
+	validUsername := subtle.ConstantTimeCompare([]byte(username), []byte(h.conf.Username)) == 1
+	validPassword := subtle.ConstantTimeCompare([]byte(password), []byte(h.conf.Password)) == 1
+	return validUsername && validPassword
+}
+
+// crossOrigin reports whether a browser sent r from another site. Browsers
+// attach cached Basic credentials to such requests, so without this check any
+// page could post incidents on behalf of a signed-in operator. Origins listed
+// in AdminConf are allowed, since behind a proxy that rewrites the Host header
+// even the admin form does not match it.
+func (h *AdminHandler) crossOrigin(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin != "" && slices.Contains(h.conf.Origins, strings.ToLower(origin)) {
+		return false
+	}
+	switch r.Header.Get("Sec-Fetch-Site") {
+	case "", "same-origin", "none":
+	default:
+		return true
+	}
+	if origin != "" {
+		parsed, err := url.Parse(origin)
+		return err != nil || parsed.Host != r.Host
+	}
+	return false
+}
+
+// incidentErrorCode maps errors of manual incident changes to HTTP status codes.
+func incidentErrorCode(err error) int {
+	switch {
+	case errors.Is(err, manager.ErrUnknownIncident):
+		return http.StatusNotFound
+	case errors.Is(err, manager.ErrInvalidIncident), errors.Is(err, manager.ErrUnknownService):
+		return http.StatusBadRequest
+	case errors.Is(err, manager.ErrIncidentResolved):
+		return http.StatusConflict
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// maintenanceErrorCode maps errors of maintenance window changes to HTTP status
+// codes.
+func maintenanceErrorCode(err error) int {
+	switch {
+	case errors.Is(err, manager.ErrUnknownWindow):
+		return http.StatusNotFound
+	case errors.Is(err, manager.ErrInvalidWindow), errors.Is(err, manager.ErrUnknownService):
+		return http.StatusBadRequest
+	default:
+		return http.StatusInternalServerError
+	}
+}
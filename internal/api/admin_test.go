@@ -0,0 +1,119 @@
+package api
+
+import (
+	"int-status/internal"
+	"int-status/internal/manager"
+	"int-status/internal/storage"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestAdminHandler(t *testing.T, origins ...string) *AdminHandler {
+	t.Helper()
+	store, err := storage.NewMemoryStorage(100, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	serviceManager, err := manager.NewServiceManager([]internal.ServiceConf{
+		{Name: "web", API: internal.APIConf{URL: "http://127.0.0.1:1/"}},
+	}, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf := internal.AdminConf{Username: "admin", Password: "secret", Origins: origins}
+	return NewAdminHandler(serviceManager, conf, time.UTC)
+}
+
+// adminRequest sends a request to the admin handler and returns the status code.
+func adminRequest(handler http.Handler, method, target string, headers map[string]string) int {
+	var body *strings.Reader
+	if method == http.MethodPost {
+		body = strings.NewReader(`{"title": "Delayed payouts", "services": ["web"], "message": "Looking into it."}`)
+	} else {
+		body = strings.NewReader("")
+	}
+	request := httptest.NewRequest(method, target, body)
+	request.Host = "tinyping.internal:8080"
+	for name, value := range headers {
+		request.Header.Set(name, value)
+	}
+	if _, ok := headers["Authorization"]; !ok {
+		request.SetBasicAuth("admin", "secret")
+	}
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	return recorder.Code
+}
+
+func TestAdminAuthorization(t *testing.T) {
+	handler := newTestAdminHandler(t)
+	tests := map[string]struct {
+		headers map[string]string
+		want    int
+	}{
+		"no credentials": {map[string]string{"Authorization": ""}, http.StatusUnauthorized},
+		"wrong password": {map[string]string{"Authorization": "Basic YWRtaW46d3Jvbmc="}, http.StatusUnauthorized},
+		"wrong username": {map[string]string{"Authorization": "Basic cm9vdDpzZWNyZXQ="}, http.StatusUnauthorized},
+		"valid":          {nil, http.StatusCreated},
+	}
+	for name, test := range tests {
+		code := adminRequest(handler, http.MethodPost, "/api/v1/admin/incidents", test.headers)
+		if code != test.want {
+			t.Errorf("%s: POST = %d, want %d", name, code, test.want)
+		}
+	}
+	request := httptest.NewRequest(http.MethodGet, "/admin", nil)
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, request)
+	if recorder.Code != http.StatusUnauthorized || recorder.Header().Get("WWW-Authenticate") == "" {
+		t.Errorf("GET /admin without credentials = %d, WWW-Authenticate %q", recorder.Code, recorder.Header().Get("WWW-Authenticate"))
+	}
+}
+
+func TestAdminRejectsCrossOriginChanges(t *testing.T) {
+	handler := newTestAdminHandler(t)
+	tests := map[string]struct {
+		headers map[string]string
+		want    int
+	}{
+		"no browser headers":     {nil, http.StatusCreated},
+		"same origin":            {map[string]string{"Sec-Fetch-Site": "same-origin", "Origin": "http://tinyping.internal:8080"}, http.StatusCreated},
+		"typed by the user":      {map[string]string{"Sec-Fetch-Site": "none"}, http.StatusCreated},
+		"cross site":             {map[string]string{"Sec-Fetch-Site": "cross-site", "Origin": "https://evil.example"}, http.StatusForbidden},
+		"same site":              {map[string]string{"Sec-Fetch-Site": "same-site"}, http.StatusForbidden},
+		"origin of another host": {map[string]string{"Origin": "https://evil.example"}, http.StatusForbidden},
+		// Behind a proxy the Host header is the upstream one, so the public
+		// origin of the form does not match it.
+		"public origin not listed": {map[string]string{"Sec-Fetch-Site": "same-origin", "Origin": "https://status.example.com"}, http.StatusForbidden},
+	}
+	for name, test := range tests {
+		code := adminRequest(handler, http.MethodPost, "/api/v1/admin/incidents", test.headers)
+		if code != test.want {
+			t.Errorf("%s: POST = %d, want %d", name, code, test.want)
+		}
+	}
+
+	// Reading is allowed from anywhere; only changes are checked.
+	code := adminRequest(handler, http.MethodGet, "/api/v1/admin/maintenance", map[string]string{"Sec-Fetch-Site": "cross-site"})
+	if code != http.StatusOK {
+		t.Errorf("cross-site GET = %d, want 200", code)
+	}
+}
+
+func TestAdminAllowsConfiguredOrigins(t *testing.T) {
+	handler := newTestAdminHandler(t, "https://status.example.com")
+	for origin, want := range map[string]int{
+		"https://status.example.com": http.StatusCreated,
+		"https://STATUS.example.com": http.StatusCreated,
+		"http://status.example.com":  http.StatusForbidden,
+		"https://evil.example":       http.StatusForbidden,
+	} {
+		headers := map[string]string{"Sec-Fetch-Site": "same-origin", "Origin": origin}
+		if code := adminRequest(handler, http.MethodPost, "/api/v1/admin/incidents", headers); code != want {
+			t.Errorf("origin %s: POST = %d, want %d", origin, code, want)
+		}
+	}
+}
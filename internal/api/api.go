@@ -0,0 +1,291 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"int-status/internal"
+	"int-status/internal/manager"
+	"int-status/internal/storage"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// defaultRange is the history returned when a request does not set "from".
+const defaultRange = 24 * time.Hour
+
+// maintenanceRange is how far ahead maintenance is listed when a request does
+// not set "to".
+const maintenanceRange = 7 * 24 * time.Hour
+
+// maxPageSize caps the "limit" query parameter of history requests.
+const maxPageSize = 1000
+
+// Handler serves the versioned JSON API backed by a ServiceManager.
+type Handler struct {
+	manager *manager.ServiceManager
+	mux     *http.ServeMux
+}
+
+// NewHandler creates the API handler and registers its routes.
+func NewHandler(serviceManager *manager.ServiceManager) *Handler {
+	h := &Handler{
+		manager: serviceManager,
+		mux:     http.NewServeMux(),
+	}
+
+	h.mux.HandleFunc("GET /api/v1/services", h.listServices)
+	h.mux.HandleFunc("GET /api/v1/services/{name}/history", h.getHistory)
+	h.mux.HandleFunc("GET /api/v1/incidents", h.listIncidents)
+	h.mux.HandleFunc("GET /api/v1/summary", h.getSummary)
+	h.mux.HandleFunc("GET /api/v1/maintenance", h.listMaintenance)
+	h.mux.HandleFunc("GET /api/v1/uptime", h.listUptime)
+	h.mux.HandleFunc("GET /api/v1/slo", h.listSLOs)
+
+	return h
+}
+
+func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// GET /api/v1/services
+func (h *Handler) listServices(w http.ResponseWriter, r *http.Request) {
+	latest, err := h.manager.GetLatestStatuses()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	services := make([]serviceJSON, 0)
+	for _, conf := range h.manager.Services() {
+		services = append(services, newServiceJSON(conf, latest))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"services": services})
+}
+
+// GET /api/v1/services/{name}/history?from=&to=&limit=&cursor=
+func (h *Handler) getHistory(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseRange(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	query := storage.HistoryQuery{From: from, To: to, Cursor: r.URL.Query().Get("cursor")}
+	if limit := r.URL.Query().Get("limit"); limit != "" {
+		query.Limit, err = strconv.Atoi(limit)
+		if err != nil || query.Limit <= 0 || query.Limit > maxPageSize {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("limit must be between 1 and %d", maxPageSize))
+			return
+		}
+	}
+
+	name := r.PathValue("name")
+	page, err := h.manager.GetServiceHistory(name, query)
+	if errors.Is(err, manager.ErrUnknownService) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if errors.Is(err, storage.ErrInvalidCursor) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	statuses := make([]statusJSON, len(page.Statuses))
+	for i, status := range page.Statuses {
+		statuses[i] = newStatusJSON(status)
+	}
+	writeJSON(w, http.StatusOK, historyJSON{
+		Service:    name,
+		From:       from,
+		To:         to,
+		Statuses:   statuses,
+		NextCursor: page.NextCursor,
+	})
+}
+
+// GET /api/v1/incidents?from=&to=
+func (h *Handler) listIncidents(w http.ResponseWriter, r *http.Request) {
+	from, to, err := parseRange(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	incidentsMap, err := h.manager.GetIncidents(from, to)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	manualIncidents, err := h.manager.GetManualIncidents(from, to)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	now := time.Now()
+	incidents := make([]incidentJSON, 0)
+	for _, conf := range h.manager.Services() {
+		for _, incident := range incidentsMap[conf.Name] {
+			incidents = append(incidents, newIncidentJSON(incident, now))
+		}
+	}
+	manual := make([]manualIncidentJSON, len(manualIncidents))
+	for i, incident := range manualIncidents {
+		manual[i] = newManualIncidentJSON(incident)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"from":             from,
+		"to":               to,
+		"incidents":        incidents,
+		"manual_incidents": manual,
+	})
+}
+
+// GET /api/v1/summary
+func (h *Handler) getSummary(w http.ResponseWriter, r *http.Request) {
+	latest, err := h.manager.GetLatestStatuses()
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	summary := summaryJSON{
+		Status: internal.StateUp,
+		Counts: make(map[internal.State]int),
+	}
+	for _, conf := range h.manager.Services() {
+		service := newServiceJSON(conf, latest)
+		state := internal.StateUnknown
+		if service.Latest != nil {
+			state = service.Latest.State
+		}
+		if state.Severity() > summary.Status.Severity() {
+			summary.Status = state
+		}
+		summary.Counts[state]++
+		summary.Services = append(summary.Services, service)
+	}
+	summary.UpdatedAt = time.Now()
+	writeJSON(w, http.StatusOK, summary)
+}
+
+// GET /api/v1/uptime
+func (h *Handler) listUptime(w http.ResponseWriter, r *http.Request) {
+	services := make([]serviceUptimeJSON, 0)
+	for _, conf := range h.manager.Services() {
+		windows, err := h.manager.GetUptime(conf.Name)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+		services = append(services, newServiceUptimeJSON(conf, windows))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"services": services})
+}
+
+// GET /api/v1/slo
+func (h *Handler) listSLOs(w http.ResponseWriter, r *http.Request) {
+	slos := make([]sloJSON, 0)
+	for _, report := range h.manager.GetSLOReports() {
+		slos = append(slos, newSLOJSON(report))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"slos": slos})
+}
+
+// GET /api/v1/maintenance?from=&to=
+// Unlike the other ranges, this one looks ahead: "from" defaults to now and
+// "to" to 7 days after "from".
+func (h *Handler) listMaintenance(w http.ResponseWriter, r *http.Request) {
+	now := time.Now()
+	from := now
+	if value := r.URL.Query().Get("from"); value != "" {
+		parsed, err := parseTime(value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid from: %v", err))
+			return
+		}
+		from = parsed
+	}
+
+	to := from.Add(maintenanceRange)
+	if value := r.URL.Query().Get("to"); value != "" {
+		parsed, err := parseTime(value)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("invalid to: %v", err))
+			return
+		}
+		to = parsed
+	}
+	if from.After(to) {
+		writeError(w, http.StatusBadRequest, errors.New("from must not be after to"))
+		return
+	}
+
+	maintenance := make([]maintenanceJSON, 0)
+	for _, occurrence := range h.manager.GetMaintenance(from, to) {
+		maintenance = append(maintenance, newMaintenanceJSON(occurrence, now))
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"from":        from,
+		"to":          to,
+		"maintenance": maintenance,
+	})
+}
+
+// parseRange reads "from" and "to" as RFC 3339 timestamps or Unix seconds.
+// "to" defaults to now and "from" to 24 hours before "to".
+func parseRange(r *http.Request) (time.Time, time.Time, error) {
+	to := time.Now()
+	if value := r.URL.Query().Get("to"); value != "" {
+		parsed, err := parseTime(value)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid to: %v", err)
+		}
+		to = parsed
+	}
+
+	from := to.Add(-defaultRange)
+	if value := r.URL.Query().Get("from"); value != "" {
+		parsed, err := parseTime(value)
+		if err != nil {
+			return time.Time{}, time.Time{}, fmt.Errorf("invalid from: %v", err)
+		}
+		from = parsed
+	}
+
+	if from.After(to) {
+		return time.Time{}, time.Time{}, errors.New("from must not be after to")
+	}
+	return from, to, nil
+}
+
+func parseTime(value string) (time.Time, error) {
+	if seconds, err := strconv.ParseInt(value, 10, 64); err == nil {
+		return time.Unix(seconds, 0), nil
+	}
+	return time.Parse(time.RFC3339, value)
+}
+
+func writeJSON(w http.ResponseWriter, code int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(body); err != nil {
+		logrus.Errorf("Error encoding API response: %v", err)
+	}
+}
+
+func writeError(w http.ResponseWriter, code int, err error) {
+	if code >= http.StatusInternalServerError {
+		logrus.Errorf("API error: %v", err)
+	}
+	writeJSON(w, code, map[string]string{"error": err.Error()})
+}
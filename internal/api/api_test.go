@@ -0,0 +1,150 @@
+package api
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"int-status/internal"
+	"int-status/internal/manager"
+	"int-status/internal/storage"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+)
+
+func newTestHandler(t *testing.T, statuses []internal.Status) *Handler {
+	t.Helper()
+	store, err := storage.NewMemoryStorage(100, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := store.UpdateHistory(statuses); err != nil {
+		t.Fatal(err)
+	}
+	serviceManager, err := manager.NewServiceManager([]internal.ServiceConf{
+		{Name: "web", API: internal.APIConf{URL: "http://127.0.0.1:1/"}},
+	}, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return NewHandler(serviceManager)
+}
+
+func get(t *testing.T, handler http.Handler, target string, body interface{}) int {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, target, nil))
+	if body != nil && recorder.Code == http.StatusOK {
+		if err := json.NewDecoder(recorder.Body).Decode(body); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return recorder.Code
+}
+
+func TestGetHistoryPages(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	var statuses []internal.Status
+	for i := 5; i > 0; i-- {
+		statuses = append(statuses, internal.Status{
+			Service:   "web",
+			Timestamp: now.Add(-time.Duration(i) * time.Minute),
+			Status:    internal.StateUp,
+		})
+	}
+	handler := newTestHandler(t, statuses)
+
+	var seen int
+	target := "/api/v1/services/web/history?limit=2"
+	for pages := 0; ; pages++ {
+		if pages > 3 {
+			t.Fatal("pagination did not end")
+		}
+		var page historyJSON
+		if code := get(t, handler, target, &page); code != http.StatusOK {
+			t.Fatalf("GET %s = %d", target, code)
+		}
+		seen += len(page.Statuses)
+		if page.NextCursor == "" {
+			break
+		}
+		target = "/api/v1/services/web/history?limit=2&cursor=" + url.QueryEscape(page.NextCursor)
+	}
+	if seen != len(statuses) {
+		t.Errorf("paged through %d statuses, want %d", seen, len(statuses))
+	}
+}
+
+func TestGetHistoryRejectsInvalidCursor(t *testing.T) {
+	handler := newTestHandler(t, nil)
+
+	for _, cursor := range []string{
+		"not base64!",
+		base64.RawURLEncoding.EncodeToString([]byte("yesterday")),
+	} {
+		target := "/api/v1/services/web/history?cursor=" + url.QueryEscape(cursor)
+		if code := get(t, handler, target, nil); code != http.StatusBadRequest {
+			t.Errorf("cursor %q: GET = %d, want 400", cursor, code)
+		}
+	}
+	if code := get(t, handler, "/api/v1/services/db/history", nil); code != http.StatusNotFound {
+		t.Errorf("unknown service: GET = %d, want 404", code)
+	}
+}
+
+func TestListIncidents(t *testing.T) {
+	store, err := storage.NewMemoryStorage(100, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now().Add(-time.Hour).Truncate(time.Second)
+	for _, incident := range []internal.Incident{
+		{ID: "resolved", Service: "web", StartTime: start, EndTime: start.Add(10 * time.Minute), Cause: "got status 503"},
+		{ID: "ongoing", Service: "web", StartTime: start.Add(30 * time.Minute), Cause: "i/o timeout"},
+	} {
+		if err := store.SaveIncident(incident); err != nil {
+			t.Fatal(err)
+		}
+	}
+	serviceManager, err := manager.NewServiceManager([]internal.ServiceConf{
+		{Name: "web", API: internal.APIConf{URL: "http://127.0.0.1:1/"}},
+	}, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var body struct {
+		Incidents []map[string]interface{} `json:"incidents"`
+	}
+	before := time.Now()
+	if code := get(t, NewHandler(serviceManager), "/api/v1/incidents", &body); code != http.StatusOK {
+		t.Fatalf("GET /api/v1/incidents = %d", code)
+	}
+	if len(body.Incidents) != 2 {
+		t.Fatalf("got %d incidents, want 2", len(body.Incidents))
+	}
+
+	// end_time is always a time: the end of a resolved incident, and the time
+	// of the request for an ongoing one.
+	for _, incident := range body.Incidents {
+		value, ok := incident["end_time"].(string)
+		if !ok {
+			t.Fatalf("incident %v: end_time = %v, want a time", incident["id"], incident["end_time"])
+		}
+		end, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			t.Fatal(err)
+		}
+		switch incident["id"] {
+		case "resolved":
+			if !end.Equal(start.Add(10*time.Minute)) || incident["ongoing"] != false {
+				t.Errorf("resolved incident ends %s, ongoing %v", end, incident["ongoing"])
+			}
+		case "ongoing":
+			if end.Before(before) || incident["ongoing"] != true || incident["cause"] != "i/o timeout" {
+				t.Errorf("ongoing incident ends %s, ongoing %v, cause %v", end, incident["ongoing"], incident["cause"])
+			}
+		}
+	}
+}
@@ -0,0 +1,473 @@
+package api
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"int-status/internal"
+	"int-status/internal/manager"
+	"net/http"
+	"sort"
+	"time"
+)
+
+// statuspageIncidentRange is how far back incidents.json looks.
+const statuspageIncidentRange = 7 * 24 * time.Hour
+
+// statuspageIncidentLimit matches the 50 most recent incidents returned by Statuspage.
+const statuspageIncidentLimit = 50
+
+// statuspageMaintenanceRange is how far ahead summary.json lists maintenance.
+const statuspageMaintenanceRange = 7 * 24 * time.Hour
+
+// StatuspageHandler publishes TinyPing's own health in the Atlassian
+// Statuspage v2 schema, so that tools which consume vendor status pages
+// (including another TinyPing) can consume this one too.
+type StatuspageHandler struct {
+	manager  *manager.ServiceManager
+	page     internal.PageConf
+	timezone string
+	mux      *http.ServeMux
+}
+
+// NewStatuspageHandler creates the handler and registers the /api/v2 routes.
+func NewStatuspageHandler(serviceManager *manager.ServiceManager, page internal.PageConf, timezone string) *StatuspageHandler {
+	h := &StatuspageHandler{
+		manager:  serviceManager,
+		page:     page,
+		timezone: timezone,
+		mux:      http.NewServeMux(),
+	}
+
+	h.mux.HandleFunc("GET /api/v2/status.json", h.getStatus)
+	h.mux.HandleFunc("GET /api/v2/summary.json", h.getSummary)
+	h.mux.HandleFunc("GET /api/v2/components.json", h.getComponents)
+	h.mux.HandleFunc("GET /api/v2/incidents.json", h.getIncidents)
+
+	return h
+}
+
+func (h *StatuspageHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	h.mux.ServeHTTP(w, r)
+}
+
+type spPage struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	URL       string    `json:"url"`
+	TimeZone  string    `json:"time_zone"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type spStatus struct {
+	Indicator   string `json:"indicator"`
+	Description string `json:"description"`
+}
+
+type spComponent struct {
+	ID                 string    `json:"id"`
+	Name               string    `json:"name"`
+	Status             string    `json:"status"`
+	CreatedAt          time.Time `json:"created_at"`
+	UpdatedAt          time.Time `json:"updated_at"`
+	Position           int       `json:"position"`
+	Description        string    `json:"description"`
+	Showcase           bool      `json:"showcase"`
+	StartDate          *string   `json:"start_date"`
+	GroupID            *string   `json:"group_id"`
+	PageID             string    `json:"page_id"`
+	Group              bool      `json:"group"`
+	OnlyShowIfDegraded bool      `json:"only_show_if_degraded"`
+}
+
+type spIncidentUpdate struct {
+	ID         string    `json:"id"`
+	Status     string    `json:"status"`
+	Body       string    `json:"body"`
+	IncidentID string    `json:"incident_id"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+	DisplayAt  time.Time `json:"display_at"`
+}
+
+type spIncident struct {
+	ID              string             `json:"id"`
+	Name            string             `json:"name"`
+	Status          string             `json:"status"`
+	CreatedAt       time.Time          `json:"created_at"`
+	UpdatedAt       time.Time          `json:"updated_at"`
+	MonitoringAt    *time.Time         `json:"monitoring_at"`
+	ResolvedAt      *time.Time         `json:"resolved_at"`
+	Impact          string             `json:"impact"`
+	Shortlink       string             `json:"shortlink"`
+	StartedAt       time.Time          `json:"started_at"`
+	PageID          string             `json:"page_id"`
+	IncidentUpdates []spIncidentUpdate `json:"incident_updates"`
+	Components      []spComponent      `json:"components"`
+}
+
+// spScheduledMaintenance is an incident with a planned time range.
+type spScheduledMaintenance struct {
+	spIncident
+	ScheduledFor   time.Time `json:"scheduled_for"`
+	ScheduledUntil time.Time `json:"scheduled_until"`
+}
+
+// snapshot is everything the four endpoints are built from.
+type snapshot struct {
+	page       spPage
+	status     spStatus
+	components []spComponent
+	byService  map[string]spComponent
+}
+
+// GET /api/v2/status.json
+func (h *StatuspageHandler) getStatus(w http.ResponseWriter, r *http.Request) {
+	snap, err := h.snapshot(r)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"page":   snap.page,
+		"status": snap.status,
+	})
+}
+
+// GET /api/v2/components.json
+func (h *StatuspageHandler) getComponents(w http.ResponseWriter, r *http.Request) {
+	snap, err := h.snapshot(r)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"page":       snap.page,
+		"components": snap.components,
+	})
+}
+
+// GET /api/v2/summary.json
+func (h *StatuspageHandler) getSummary(w http.ResponseWriter, r *http.Request) {
+	snap, err := h.snapshot(r)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	incidents, err := h.incidents(snap)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	unresolved := make([]spIncident, 0)
+	for _, incident := range incidents {
+		if incident.ResolvedAt == nil {
+			unresolved = append(unresolved, incident)
+		}
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"page":                   snap.page,
+		"components":             snap.components,
+		"incidents":              unresolved,
+		"scheduled_maintenances": h.scheduledMaintenances(snap),
+		"status":                 snap.status,
+	})
+}
+
+// GET /api/v2/incidents.json
+func (h *StatuspageHandler) getIncidents(w http.ResponseWriter, r *http.Request) {
+	snap, err := h.snapshot(r)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	incidents, err := h.incidents(snap)
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"page":      snap.page,
+		"incidents": incidents,
+	})
+}
+
+func (h *StatuspageHandler) snapshot(r *http.Request) (*snapshot, error) {
+	latest, err := h.manager.GetLatestStatuses()
+	if err != nil {
+		return nil, err
+	}
+
+	pageURL := h.page.URL
+	if pageURL == "" {
+		scheme := "http"
+		if r.TLS != nil {
+			scheme = "https"
+		}
+		pageURL = scheme + "://" + r.Host
+	}
+
+	snap := &snapshot{
+		page: spPage{
+			ID:       stableID(h.page.Name),
+			Name:     h.page.Name,
+			URL:      pageURL,
+			TimeZone: h.timezone,
+		},
+		byService: make(map[string]spComponent),
+	}
+
+	var states []internal.State
+	for i, conf := range h.manager.Services() {
+		state := internal.StateUnknown
+		var updatedAt time.Time
+		if status, ok := latest[conf.Name]; ok {
+			state = status.State()
+			updatedAt = status.Timestamp
+		}
+		if updatedAt.After(snap.page.UpdatedAt) {
+			snap.page.UpdatedAt = updatedAt
+		}
+		states = append(states, state)
+
+		component := spComponent{
+			ID:          stableID(h.page.Name + "/" + conf.Name),
+			Name:        conf.Name,
+			Status:      componentStatus(state),
+			CreatedAt:   updatedAt,
+			UpdatedAt:   updatedAt,
+			Position:    i + 1,
+			Description: conf.Description,
+			Showcase:    true,
+			PageID:      snap.page.ID,
+		}
+		snap.components = append(snap.components, component)
+		snap.byService[conf.Name] = component
+	}
+	if snap.page.UpdatedAt.IsZero() {
+		snap.page.UpdatedAt = time.Now()
+	}
+	snap.status = pageStatus(states)
+	return snap, nil
+}
+
+// incidents returns the most recent incidents, newest first.
+func (h *StatuspageHandler) incidents(snap *snapshot) ([]spIncident, error) {
+	to := time.Now()
+	incidentsMap, err := h.manager.GetIncidents(to.Add(-statuspageIncidentRange), to)
+	if err != nil {
+		return nil, err
+	}
+
+	manualIncidents, err := h.manager.GetManualIncidents(to.Add(-statuspageIncidentRange), to)
+	if err != nil {
+		return nil, err
+	}
+
+	incidents := make([]spIncident, 0)
+	for _, serviceIncidents := range incidentsMap {
+		for _, incident := range serviceIncidents {
+			incidents = append(incidents, newSPIncident(snap, incident))
+		}
+	}
+	for _, incident := range manualIncidents {
+		incidents = append(incidents, newSPManualIncident(snap, incident))
+	}
+
+	sort.Slice(incidents, func(i, j int) bool {
+		return incidents[i].StartedAt.After(incidents[j].StartedAt)
+	})
+	if len(incidents) > statuspageIncidentLimit {
+		incidents = incidents[:statuspageIncidentLimit]
+	}
+	return incidents, nil
+}
+
+func newSPIncident(snap *snapshot, incident internal.Incident) spIncident {
+	id := incident.ID
+	converted := spIncident{
+		ID:         id,
+		Name:       incident.Service + " is down",
+		Status:     "investigating",
+		CreatedAt:  incident.StartTime,
+		UpdatedAt:  incident.StartTime,
+		Impact:     "major",
+		Shortlink:  snap.page.URL,
+		StartedAt:  incident.StartTime,
+		PageID:     snap.page.ID,
+		Components: []spComponent{snap.byService[incident.Service]},
+		IncidentUpdates: []spIncidentUpdate{{
+			ID:         stableID(id + "/investigating"),
+			Status:     "investigating",
+			Body:       incident.Service + " is not responding to health checks.",
+			IncidentID: id,
+			CreatedAt:  incident.StartTime,
+			UpdatedAt:  incident.StartTime,
+			DisplayAt:  incident.StartTime,
+		}},
+	}
+
+	if !incident.Ongoing() {
+		resolvedAt := incident.EndTime
+		converted.Status = "resolved"
+		converted.UpdatedAt = resolvedAt
+		converted.ResolvedAt = &resolvedAt
+		converted.IncidentUpdates = append([]spIncidentUpdate{{
+			ID:         stableID(id + "/resolved"),
+			Status:     "resolved",
+			Body:       incident.Service + " has recovered.",
+			IncidentID: id,
+			CreatedAt:  resolvedAt,
+			UpdatedAt:  resolvedAt,
+			DisplayAt:  resolvedAt,
+		}}, converted.IncidentUpdates...)
+	}
+	return converted
+}
+
+// newSPManualIncident converts an incident posted by an operator, whose stages
+// are Statuspage's own. Operators do not rate the impact, so it is minor.
+func newSPManualIncident(snap *snapshot, incident internal.ManualIncident) spIncident {
+	converted := spIncident{
+		ID:              incident.ID,
+		Name:            incident.Title,
+		Status:          string(incident.Status()),
+		CreatedAt:       incident.StartTime,
+		UpdatedAt:       incident.StartTime,
+		Impact:          "minor",
+		Shortlink:       snap.page.URL,
+		StartedAt:       incident.StartTime,
+		PageID:          snap.page.ID,
+		IncidentUpdates: make([]spIncidentUpdate, 0, len(incident.Updates)),
+	}
+	for _, service := range incident.Services {
+		if component, ok := snap.byService[service]; ok {
+			converted.Components = append(converted.Components, component)
+		}
+	}
+
+	// Statuspage lists updates newest first.
+	for i := len(incident.Updates) - 1; i >= 0; i-- {
+		update := incident.Updates[i]
+		converted.IncidentUpdates = append(converted.IncidentUpdates, spIncidentUpdate{
+			ID:         stableID(fmt.Sprintf("%s/%d", incident.ID, i)),
+			Status:     string(update.Status),
+			Body:       update.Message,
+			IncidentID: incident.ID,
+			CreatedAt:  update.Timestamp,
+			UpdatedAt:  update.Timestamp,
+			DisplayAt:  update.Timestamp,
+		})
+		if update.Timestamp.After(converted.UpdatedAt) {
+			converted.UpdatedAt = update.Timestamp
+		}
+		if update.Status == internal.IncidentMonitoring {
+			monitoringAt := update.Timestamp
+			converted.MonitoringAt = &monitoringAt
+		}
+	}
+	if !incident.Ongoing() {
+		resolvedAt := incident.EndTime
+		converted.ResolvedAt = &resolvedAt
+	}
+	return converted
+}
+
+// scheduledMaintenances returns the maintenance that is in progress or starts
+// within statuspageMaintenanceRange.
+func (h *StatuspageHandler) scheduledMaintenances(snap *snapshot) []spScheduledMaintenance {
+	now := time.Now()
+	maintenances := make([]spScheduledMaintenance, 0)
+	for _, maintenance := range h.manager.GetMaintenance(now, now.Add(statuspageMaintenanceRange)) {
+		status := "scheduled"
+		if maintenance.Active(now) {
+			status = "in_progress"
+		}
+
+		// Occurrences of recurring windows share the window ID.
+		id := stableID(maintenance.WindowID + "/" + maintenance.Start.UTC().Format(time.RFC3339))
+		converted := spScheduledMaintenance{
+			spIncident: spIncident{
+				ID:              id,
+				Name:            maintenance.Title,
+				Status:          status,
+				CreatedAt:       maintenance.Start,
+				UpdatedAt:       maintenance.Start,
+				Impact:          "maintenance",
+				Shortlink:       snap.page.URL,
+				StartedAt:       maintenance.Start,
+				PageID:          snap.page.ID,
+				IncidentUpdates: []spIncidentUpdate{},
+			},
+			ScheduledFor:   maintenance.Start,
+			ScheduledUntil: maintenance.End,
+		}
+		for _, service := range maintenance.Services {
+			if component, ok := snap.byService[service]; ok {
+				converted.Components = append(converted.Components, component)
+			}
+		}
+		maintenances = append(maintenances, converted)
+	}
+	return maintenances
+}
+
+// componentStatus maps a state to a Statuspage component status. Statuspage
+// has no "unknown", and a service that has no result yet, or whose last check
+// could not tell, is not known to be impaired, so UNKNOWN is operational. A
+// service that keeps going up and down is a partial outage.
+func componentStatus(state internal.State) string {
+	switch state {
+	case internal.StateDegraded:
+		return "degraded_performance"
+	case internal.StateMaintenance:
+		return "under_maintenance"
+	case internal.StateDown:
+		return "major_outage"
+	case internal.StateFlapping:
+		return "partial_outage"
+	default:
+		return "operational"
+	}
+}
+
+// pageStatus derives the page indicator the way Statuspage does: critical
+// when everything is down, major when something is, minor when something is
+// degraded.
+func pageStatus(states []internal.State) spStatus {
+	down, partial, degraded, maintenance := 0, 0, 0, 0
+	for _, state := range states {
+		switch componentStatus(state) {
+		case "major_outage":
+			down++
+		case "partial_outage":
+			partial++
+		case "degraded_performance":
+			degraded++
+		case "under_maintenance":
+			maintenance++
+		}
+	}
+
+	switch {
+	case down > 0 && down == len(states):
+		return spStatus{"critical", "Major System Outage"}
+	case down > 0 || partial > 0:
+		return spStatus{"major", "Partial System Outage"}
+	case degraded > 0:
+		return spStatus{"minor", "Minor Service Outage"}
+	case maintenance > 0:
+		return spStatus{"maintenance", "Service Under Maintenance"}
+	default:
+		return spStatus{"none", "All Systems Operational"}
+	}
+}
+
+// stableID derives a Statuspage-style 12 character ID from a name, so that IDs
+// survive restarts without being stored.
+func stableID(name string) string {
+	sum := sha1.Sum([]byte(name))
+	return hex.EncodeToString(sum[:])[:12]
+}
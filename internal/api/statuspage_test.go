@@ -0,0 +1,66 @@
+package api
+
+import (
+	"int-status/internal"
+	"int-status/internal/manager"
+	"int-status/internal/storage"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestStatuspageComponentStatus(t *testing.T) {
+	store, err := storage.NewMemoryStorage(100, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	if err := store.UpdateHistory([]internal.Status{
+		{Service: "web", Timestamp: now, Status: internal.StateUp},
+		{Service: "dns", Timestamp: now, Status: internal.StateUnknown},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	var services []internal.ServiceConf
+	for _, name := range []string{"web", "dns", "new"} {
+		services = append(services, internal.ServiceConf{Name: name, API: internal.APIConf{URL: "http://127.0.0.1:1/"}})
+	}
+	serviceManager, err := manager.NewServiceManager(services, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	handler := NewStatuspageHandler(serviceManager, internal.PageConf{Name: "TinyPing"}, "UTC")
+
+	// UNKNOWN and services without a result yet are not reported as impaired.
+	var summary struct {
+		Status     spStatus      `json:"status"`
+		Components []spComponent `json:"components"`
+	}
+	if code := get(t, handler, "/api/v2/summary.json", &summary); code != http.StatusOK {
+		t.Fatalf("GET summary.json = %d", code)
+	}
+	if summary.Status.Indicator != "none" {
+		t.Errorf("indicator = %q, want none", summary.Status.Indicator)
+	}
+	for _, component := range summary.Components {
+		if component.Status != "operational" {
+			t.Errorf("component %s is %q, want operational", component.Name, component.Status)
+		}
+	}
+	if len(summary.Components) != len(services) {
+		t.Errorf("got %d components, want %d", len(summary.Components), len(services))
+	}
+
+	for state, want := range map[internal.State]string{
+		internal.StateUp:          "operational",
+		internal.StateUnknown:     "operational",
+		internal.StateDegraded:    "degraded_performance",
+		internal.StateFlapping:    "partial_outage",
+		internal.StateDown:        "major_outage",
+		internal.StateMaintenance: "under_maintenance",
+	} {
+		if got := componentStatus(state); got != want {
+			t.Errorf("componentStatus(%s) = %q, want %q", state, got, want)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package api
+
+import (
+	"int-status/internal"
+	"int-status/internal/manager"
+	"time"
+)
+
+// serviceJSON describes a monitored service. Request details such as headers
+// are deliberately left out since they may hold credentials.
+type serviceJSON struct {
+	Name        string      `json:"name"`
+	Description string      `json:"description"`
+	Type        string      `json:"type"`
+	Interval    string      `json:"interval"`
+	Latest      *statusJSON `json:"latest"`
+}
+
+type statusJSON struct {
+	Timestamp   time.Time        `json:"timestamp"`
+	Status      internal.State   `json:"status"`
+	State       internal.State   `json:"state"`
+	LatencyMs   int64            `json:"latency_ms"`
+	Assertion   string           `json:"assertion,omitempty"`
+	Message     string           `json:"message,omitempty"`
+	Components  []componentJSON  `json:"components,omitempty"`
+	Certificate *certificateJSON `json:"certificate,omitempty"`
+}
+
+type certificateJSON struct {
+	Subject       string    `json:"subject"`
+	Issuer        string    `json:"issuer"`
+	NotAfter      time.Time `json:"not_after"`
+	DaysLeft      int       `json:"days_left"`
+	DNSNames      []string  `json:"dns_names"`
+	HostnameValid bool      `json:"hostname_valid"`
+	ChainValid    bool      `json:"chain_valid"`
+}
+
+type componentJSON struct {
+	Name   string         `json:"name"`
+	Status internal.State `json:"status"`
+}
+
+type historyJSON struct {
+	Service    string       `json:"service"`
+	From       time.Time    `json:"from"`
+	To         time.Time    `json:"to"`
+	Statuses   []statusJSON `json:"statuses"`
+	NextCursor string       `json:"next_cursor,omitempty"`
+}
+
+type incidentJSON struct {
+	ID        string    `json:"id"`
+	Service   string    `json:"service"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	Ongoing   bool      `json:"ongoing"`
+	Cause     string    `json:"cause,omitempty"`
+}
+
+type manualIncidentJSON struct {
+	ID        string                  `json:"id"`
+	Title     string                  `json:"title"`
+	Services  []string                `json:"services"`
+	Status    internal.IncidentStatus `json:"status"`
+	StartTime time.Time               `json:"start_time"`
+	EndTime   *time.Time              `json:"end_time"`
+	Ongoing   bool                    `json:"ongoing"`
+	Updates   []incidentUpdateJSON    `json:"updates"`
+}
+
+type incidentUpdateJSON struct {
+	Timestamp time.Time               `json:"timestamp"`
+	Status    internal.IncidentStatus `json:"status"`
+	Message   string                  `json:"message"`
+}
+
+type maintenanceJSON struct {
+	WindowID  string    `json:"window_id"`
+	Title     string    `json:"title"`
+	Services  []string  `json:"services"`
+	StartTime time.Time `json:"start_time"`
+	EndTime   time.Time `json:"end_time"`
+	Active    bool      `json:"active"`
+}
+
+// maintenanceWindowJSON describes a window as configured. Occurrences of
+// recurring windows are listed by GET /api/v1/maintenance.
+type maintenanceWindowJSON struct {
+	ID       string     `json:"id"`
+	Title    string     `json:"title"`
+	Services []string   `json:"services,omitempty"`
+	Groups   []string   `json:"groups,omitempty"`
+	Start    *time.Time `json:"start,omitempty"`
+	End      *time.Time `json:"end,omitempty"`
+	Schedule string     `json:"schedule,omitempty"`
+	Duration string     `json:"duration,omitempty"`
+}
+
+type serviceUptimeJSON struct {
+	Name    string       `json:"name"`
+	SLA     *float64     `json:"sla"`
+	Windows []uptimeJSON `json:"windows"`
+}
+
+// uptimeJSON is the availability over one window. Percent is null when the
+// service was neither up nor down within the window.
+type uptimeJSON struct {
+	Window             string   `json:"window"`
+	Percent            *float64 `json:"percent"`
+	UpSeconds          int64    `json:"up_seconds"`
+	DownSeconds        int64    `json:"down_seconds"`
+	MaintenanceSeconds int64    `json:"maintenance_seconds"`
+	UnknownSeconds     int64    `json:"unknown_seconds"`
+	Breached           bool     `json:"breached"`
+}
+
+// sloJSON is the state of the SLO of a service. Availability is null while
+// no check has been counted.
+type sloJSON struct {
+	Name                 string             `json:"name"`
+	Target               float64            `json:"target"`
+	LatencyMs            int64              `json:"latency_ms,omitempty"`
+	Window               string             `json:"window"`
+	GoodChecks           int                `json:"good_checks"`
+	BadChecks            int                `json:"bad_checks"`
+	Availability         *float64           `json:"availability"`
+	ErrorBudgetRemaining float64            `json:"error_budget_remaining"`
+	BurnRates            map[string]float64 `json:"burn_rates"`
+	Burning              bool               `json:"burning"`
+}
+
+type summaryJSON struct {
+	Status    internal.State         `json:"status"`
+	Counts    map[internal.State]int `json:"counts"`
+	Services  []serviceJSON          `json:"services"`
+	UpdatedAt time.Time              `json:"updated_at"`
+}
+
+func newServiceJSON(conf internal.ServiceConf, latest map[string]internal.Status) serviceJSON {
+	checkType := conf.Type
+	if checkType == "" {
+		checkType = "http"
+	}
+
+	service := serviceJSON{
+		Name:        conf.Name,
+		Description: conf.Description,
+		Type:        checkType,
+		Interval:    conf.Interval.String(),
+	}
+	if status, ok := latest[conf.Name]; ok {
+		converted := newStatusJSON(status)
+		service.Latest = &converted
+	}
+	return service
+}
+
+func newStatusJSON(status internal.Status) statusJSON {
+	converted := statusJSON{
+		Timestamp: status.Timestamp,
+		Status:    status.Status,
+		State:     status.State(),
+		LatencyMs: status.Latency,
+		Assertion: status.Assertion,
+		Message:   status.Message,
+	}
+	if cert := status.Certificate; cert != nil {
+		converted.Certificate = &certificateJSON{
+			Subject:       cert.Subject,
+			Issuer:        cert.Issuer,
+			NotAfter:      cert.NotAfter,
+			DaysLeft:      cert.DaysLeft,
+			DNSNames:      cert.DNSNames,
+			HostnameValid: cert.HostnameValid,
+			ChainValid:    cert.ChainValid,
+		}
+	}
+	for _, component := range status.Components {
+		converted.Components = append(converted.Components, componentJSON{
+			Name:   component.Name,
+			Status: component.Status,
+		})
+	}
+	return converted
+}
+
+// newIncidentJSON converts incident. An ongoing incident ends at now, so that
+// end_time is always a time, as it was before incidents were stored.
+func newIncidentJSON(incident internal.Incident, now time.Time) incidentJSON {
+	converted := incidentJSON{
+		ID:        incident.ID,
+		Service:   incident.Service,
+		StartTime: incident.StartTime,
+		EndTime:   incident.EndTime,
+		Ongoing:   incident.Ongoing(),
+		Cause:     incident.Cause,
+	}
+	if incident.Ongoing() {
+		converted.EndTime = now
+	}
+	return converted
+}
+
+func newManualIncidentJSON(incident internal.ManualIncident) manualIncidentJSON {
+	converted := manualIncidentJSON{
+		ID:        incident.ID,
+		Title:     incident.Title,
+		Services:  incident.Services,
+		Status:    incident.Status(),
+		StartTime: incident.StartTime,
+		Ongoing:   incident.Ongoing(),
+		Updates:   make([]incidentUpdateJSON, len(incident.Updates)),
+	}
+	if !incident.Ongoing() {
+		endTime := incident.EndTime
+		converted.EndTime = &endTime
+	}
+	for i, update := range incident.Updates {
+		converted.Updates[i] = incidentUpdateJSON{
+			Timestamp: update.Timestamp,
+			Status:    update.Status,
+			Message:   update.Message,
+		}
+	}
+	return converted
+}
+
+func newMaintenanceJSON(maintenance internal.Maintenance, now time.Time) maintenanceJSON {
+	return maintenanceJSON{
+		WindowID:  maintenance.WindowID,
+		Title:     maintenance.Title,
+		Services:  maintenance.Services,
+		StartTime: maintenance.Start,
+		EndTime:   maintenance.End,
+		Active:    maintenance.Active(now),
+	}
+}
+
+func newMaintenanceWindowJSON(window internal.MaintenanceWindow) maintenanceWindowJSON {
+	converted := maintenanceWindowJSON{
+		ID:       window.ID,
+		Title:    window.Title,
+		Services: window.Services,
+		Groups:   window.Groups,
+		Schedule: window.Schedule,
+	}
+	if window.Recurring() {
+		converted.Duration = window.Duration.String()
+	} else {
+		start, end := window.Start, window.End
+		converted.Start, converted.End = &start, &end
+	}
+	return converted
+}
+
+func newServiceUptimeJSON(conf internal.ServiceConf, windows []manager.ServiceUptime) serviceUptimeJSON {
+	converted := serviceUptimeJSON{
+		Name:    conf.Name,
+		Windows: make([]uptimeJSON, len(windows)),
+	}
+	if conf.SLA > 0 {
+		sla := conf.SLA
+		converted.SLA = &sla
+	}
+	for i, window := range windows {
+		converted.Windows[i] = uptimeJSON{
+			Window:             window.Window.Key,
+			UpSeconds:          int64(window.Uptime.Up.Seconds()),
+			DownSeconds:        int64(window.Uptime.Down.Seconds()),
+			MaintenanceSeconds: int64(window.Uptime.Maintenance.Seconds()),
+			UnknownSeconds:     int64(window.Uptime.Unknown.Seconds()),
+			Breached:           window.Breached,
+		}
+		if window.HasData {
+			percent := window.Percentage
+			converted.Windows[i].Percent = &percent
+		}
+	}
+	return converted
+}
+
+func newSLOJSON(report internal.SLOReport) sloJSON {
+	converted := sloJSON{
+		Name:                 report.Service,
+		Target:               report.Objective.Target,
+		LatencyMs:            report.Objective.Latency.Milliseconds(),
+		Window:               report.Objective.Window.String(),
+		GoodChecks:           report.Good,
+		BadChecks:            report.Bad,
+		ErrorBudgetRemaining: report.BudgetRemaining,
+		BurnRates:            make(map[string]float64, len(report.BurnRates)),
+		Burning:              report.Burning,
+	}
+	if availability, ok := report.Availability(); ok {
+		converted.Availability = &availability
+	}
+	for _, rate := range report.BurnRates {
+		converted.BurnRates[rate.Window] = rate.Rate
+	}
+	return converted
+}
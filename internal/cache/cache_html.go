@@ -5,35 +5,52 @@ import (
 	"time"
 )
 
-type HTMLCache struct {
+type entry struct {
 	content    string
 	lastUpdate time.Time
-	ttl        time.Duration
-	mu         sync.RWMutex
+}
+
+// HTMLCache caches rendered pages per key, e.g. per selected time window.
+type HTMLCache struct {
+	entries map[string]entry
+	ttl     time.Duration
+	mu      sync.RWMutex
 }
 
 func NewHTMLCache(ttl time.Duration) *HTMLCache {
 	return &HTMLCache{
-		ttl: ttl,
+		entries: make(map[string]entry),
+		ttl:     ttl,
 	}
 }
 
-func (c *HTMLCache) Get() (string, bool) {
-	if c.content == "" {
+func (c *HTMLCache) Get(key string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+
+	cached, ok := c.entries[key]
+	if !ok || cached.content == "" {
 		return "", false
 	}
 
-	if time.Since(c.lastUpdate) > c.ttl {
+	if time.Since(cached.lastUpdate) > c.ttl {
 		return "", false
 	}
 
-	return c.content, true
+	return cached.content, true
 }
 
-func (c *HTMLCache) Set(content string) {
+func (c *HTMLCache) Set(key string, content string) {
 	c.mu.Lock()
 	defer c.mu.Unlock()
 
-	c.content = content
-	c.lastUpdate = time.Now()
+	for k, cached := range c.entries {
+		if time.Since(cached.lastUpdate) > c.ttl {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = entry{
+		content:    content,
+		lastUpdate: time.Now(),
+	}
 }
@@ -4,20 +4,387 @@ import (
 	"fmt"
 	"gopkg.in/yaml.v3"
 	"int-status/internal"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 )
 
-// LoadServices loads the YAML configuration file.
-func LoadServices(path string) ([]internal.ServiceConf, error) {
+// Built-in defaults used when config.yaml does not set them.
+const (
+	defaultInterval   = 1 * time.Minute
+	defaultTimeout    = 3 * time.Second
+	defaultRetryDelay = 2 * time.Second
+	defaultFlapWindow = 10 * time.Minute
+	defaultSLOWindow  = 30 * 24 * time.Hour
+
+	// The SLO window must cover the longest burn rate window, and the
+	// per-minute counts of longer ones would take up too much memory.
+	minSLOWindow = 24 * time.Hour
+	maxSLOWindow = 90 * 24 * time.Hour
+
+	defaultTimezone = "Asia/Seoul"
+	defaultPageName = "TinyPing"
+
+	defaultAdminUsername = "admin"
+
+	defaultStorageBackend = "dynamodb"
+	defaultSQLitePath     = "./data/tinyping.db"
+	defaultMemoryCapacity = 1440
+)
+
+// Load loads the YAML configuration file. The file is either a mapping with
+// "defaults" and "services", or, for older configurations, a plain list of services.
+func Load(path string) (*internal.Config, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read YAML file: %w", err)
 	}
 
-	var services []internal.ServiceConf
-	if err := yaml.Unmarshal(data, &services); err != nil {
+	var root yaml.Node
+	if err := yaml.Unmarshal(data, &root); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal YAML: %w", err)
 	}
 
-	return services, nil
+	var conf internal.Config
+	var explicit explicitConfig
+	if len(root.Content) > 0 && root.Content[0].Kind == yaml.SequenceNode {
+		err = root.Decode(&conf.Services)
+		if err == nil {
+			err = root.Decode(&explicit.Services)
+		}
+	} else {
+		err = root.Decode(&conf)
+		if err == nil {
+			err = root.Decode(&explicit)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal YAML: %w", err)
+	}
+
+	applyEnv(&conf)
+	applyDefaults(&conf, explicit)
+
+	location, err := time.LoadLocation(conf.Timezone)
+	if err != nil {
+		return nil, fmt.Errorf("invalid timezone %q: %w", conf.Timezone, err)
+	}
+	localizeMaintenance(conf.Maintenance, explicit.Maintenance, location)
+
+	baseDir := filepath.Dir(path)
+	for i := range conf.Services {
+		if err := validateChecks(conf.Services[i]); err != nil {
+			return nil, fmt.Errorf("service %s: %w", conf.Services[i].Name, err)
+		}
+		if sla := conf.Services[i].SLA; sla < 0 || sla > 100 {
+			return nil, fmt.Errorf("service %s: sla must be a percentage, got %v", conf.Services[i].Name, sla)
+		}
+		if err := validateSLO(conf.Services[i].SLO); err != nil {
+			return nil, fmt.Errorf("service %s: %w", conf.Services[i].Name, err)
+		}
+		if err := resolveBodyFile(&conf.Services[i], baseDir); err != nil {
+			return nil, err
+		}
+		resolveNotifiers(conf.Services[i].Notifiers, baseDir)
+	}
+	resolveNotifiers(conf.Notifiers, baseDir)
+	conf.Admin.Password = os.ExpandEnv(conf.Admin.Password)
+	if err := normalizeOrigins(conf.Admin.Origins); err != nil {
+		return nil, fmt.Errorf("admin: %w", err)
+	}
+
+	return &conf, nil
+}
+
+// LoadServices loads only the services of the YAML configuration file.
+func LoadServices(path string) ([]internal.ServiceConf, error) {
+	conf, err := Load(path)
+	if err != nil {
+		return nil, err
+	}
+	return conf.Services, nil
+}
+
+// applyEnv lets environment variables override the timezone and storage
+// settings, so deployments can switch them without editing config.yaml.
+func applyEnv(conf *internal.Config) {
+	overrides := map[string]*string{
+		"TIMEZONE":             &conf.Timezone,
+		"STORAGE_BACKEND":      &conf.Storage.Backend,
+		"AWS_REGION":           &conf.Storage.DynamoDB.Region,
+		"DYNAMODB_TABLE_NAME":  &conf.Storage.DynamoDB.Table,
+		"SQLITE_PATH":          &conf.Storage.SQLite.Path,
+		"MEMORY_SNAPSHOT_PATH": &conf.Storage.Memory.SnapshotPath,
+	}
+	for key, target := range overrides {
+		if value := os.Getenv(key); value != "" {
+			*target = value
+		}
+	}
+}
+
+// explicitConfig records which check settings config.yaml sets, in defaults
+// and per service. Only settings that are absent take a default, so that an
+// explicit zero keeps its meaning, e.g. "retries: 0" opts a service out of
+// the retries of defaults, and "interval: 0" is reported instead of replaced.
+// It also keeps the maintenance times as written, to tell whether they have
+// an offset.
+type explicitConfig struct {
+	Defaults    explicitSettings      `yaml:"defaults"`
+	Maintenance []explicitMaintenance `yaml:"maintenance"`
+	Services    []explicitSettings    `yaml:"services"`
+}
+
+type explicitMaintenance struct {
+	Start string `yaml:"start"`
+	End   string `yaml:"end"`
+}
+
+type explicitSettings struct {
+	Interval          *time.Duration `yaml:"interval"`
+	Timeout           *time.Duration `yaml:"timeout"`
+	Retries           *int           `yaml:"retries"`
+	RetryDelay        *time.Duration `yaml:"retry_delay"`
+	FailureThreshold  *int           `yaml:"failure_threshold"`
+	RecoveryThreshold *int           `yaml:"recovery_threshold"`
+	SLA               *float64       `yaml:"sla"`
+	Flapping          struct {
+		Threshold *int           `yaml:"threshold"`
+		Window    *time.Duration `yaml:"window"`
+	} `yaml:"flapping"`
+	SLO struct {
+		Target  *float64       `yaml:"target"`
+		Latency *time.Duration `yaml:"latency"`
+		Window  *time.Duration `yaml:"window"`
+	} `yaml:"slo"`
+}
+
+// applyDefaults fills unset global settings and per-service check settings.
+// explicit records the settings config.yaml sets.
+func applyDefaults(conf *internal.Config, explicit explicitConfig) {
+	defaults := &conf.Defaults
+	if explicit.Defaults.Interval == nil {
+		defaults.Interval = defaultInterval
+	}
+	if explicit.Defaults.Timeout == nil {
+		defaults.Timeout = defaultTimeout
+	}
+	if explicit.Defaults.RetryDelay == nil {
+		defaults.RetryDelay = defaultRetryDelay
+	}
+	if explicit.Defaults.FailureThreshold == nil {
+		defaults.FailureThreshold = 1
+	}
+	if explicit.Defaults.RecoveryThreshold == nil {
+		defaults.RecoveryThreshold = 1
+	}
+	if explicit.Defaults.Flapping.Window == nil {
+		defaults.Flapping.Window = defaultFlapWindow
+	}
+	if explicit.Defaults.SLO.Window == nil {
+		defaults.SLO.Window = defaultSLOWindow
+	}
+
+	if conf.Timezone == "" {
+		conf.Timezone = defaultTimezone
+	}
+	if conf.Page.Name == "" {
+		conf.Page.Name = defaultPageName
+	}
+	if conf.Storage.Backend == "" {
+		conf.Storage.Backend = defaultStorageBackend
+	}
+	if conf.Storage.SQLite.Path == "" {
+		conf.Storage.SQLite.Path = defaultSQLitePath
+	}
+	if conf.Storage.Memory.Capacity <= 0 {
+		conf.Storage.Memory.Capacity = defaultMemoryCapacity
+	}
+	if conf.Admin.Username == "" {
+		conf.Admin.Username = defaultAdminUsername
+	}
+	for i := range conf.Maintenance {
+		conf.Maintenance[i].ID = fmt.Sprintf("config-%d", i+1)
+	}
+
+	for i := range conf.Services {
+		service := &conf.Services[i]
+		var set explicitSettings
+		if i < len(explicit.Services) {
+			set = explicit.Services[i]
+		}
+		if set.Interval == nil {
+			service.Interval = defaults.Interval
+		}
+		if set.Timeout == nil {
+			service.Timeout = defaults.Timeout
+		}
+		if set.Retries == nil {
+			service.Retries = defaults.Retries
+		}
+		if set.RetryDelay == nil {
+			service.RetryDelay = defaults.RetryDelay
+		}
+		if set.FailureThreshold == nil {
+			service.FailureThreshold = defaults.FailureThreshold
+		}
+		if set.RecoveryThreshold == nil {
+			service.RecoveryThreshold = defaults.RecoveryThreshold
+		}
+		if set.Flapping.Threshold == nil {
+			service.Flapping.Threshold = defaults.Flapping.Threshold
+		}
+		if set.Flapping.Window == nil {
+			service.Flapping.Window = defaults.Flapping.Window
+		}
+		if set.SLA == nil {
+			service.SLA = defaults.SLA
+		}
+		if set.SLO.Target == nil {
+			service.SLO.Target = defaults.SLO.Target
+		}
+		if set.SLO.Latency == nil {
+			service.SLO.Latency = defaults.SLO.Latency
+		}
+		if set.SLO.Window == nil {
+			service.SLO.Window = defaults.SLO.Window
+		}
+	}
+}
+
+// validateChecks checks the check settings of a service once defaults are
+// applied.
+func validateChecks(service internal.ServiceConf) error {
+	switch {
+	case service.Interval <= 0:
+		return fmt.Errorf("interval must be positive, got %s", service.Interval)
+	case service.Timeout <= 0:
+		return fmt.Errorf("timeout must be positive, got %s", service.Timeout)
+	case service.Retries < 0:
+		return fmt.Errorf("retries must not be negative, got %d", service.Retries)
+	case service.RetryDelay < 0:
+		return fmt.Errorf("retry_delay must not be negative, got %s", service.RetryDelay)
+	case service.FailureThreshold < 1:
+		return fmt.Errorf("failure_threshold must be at least 1, got %d", service.FailureThreshold)
+	case service.RecoveryThreshold < 1:
+		return fmt.Errorf("recovery_threshold must be at least 1, got %d", service.RecoveryThreshold)
+	case service.Flapping.Threshold < 0:
+		return fmt.Errorf("flapping.threshold must not be negative, got %d", service.Flapping.Threshold)
+	case service.Flapping.Threshold > 0 && service.Flapping.Window <= 0:
+		return fmt.Errorf("flapping.window must be positive, got %s", service.Flapping.Window)
+	}
+	return nil
+}
+
+// validateSLO checks an objective. A target of 100% leaves no error budget to
+// track, so it is rejected.
+func validateSLO(slo internal.SLOConf) error {
+	if !slo.Enabled() {
+		return nil
+	}
+	if slo.Target >= 100 {
+		return fmt.Errorf("slo.target must be below 100, got %v", slo.Target)
+	}
+	if slo.Window < minSLOWindow || slo.Window > maxSLOWindow {
+		return fmt.Errorf("slo.window must be between %s and %s, got %s", minSLOWindow, maxSLOWindow, slo.Window)
+	}
+	return nil
+}
+
+// localTimestampLayouts are the YAML timestamp formats without an offset.
+var localTimestampLayouts = []string{
+	"2006-1-2 15:4:5.999999999",
+	"2006-1-2",
+}
+
+// localizeMaintenance reads the one-off maintenance times that have no offset,
+// e.g. "2025-03-01 22:00:00", in location rather than in UTC, as schedules are.
+func localizeMaintenance(windows []internal.MaintenanceWindow, explicit []explicitMaintenance, location *time.Location) {
+	for i := range windows {
+		if i >= len(explicit) {
+			break
+		}
+		if start, ok := parseLocalTimestamp(explicit[i].Start, location); ok {
+			windows[i].Start = start
+		}
+		if end, ok := parseLocalTimestamp(explicit[i].End, location); ok {
+			windows[i].End = end
+		}
+	}
+}
+
+// parseLocalTimestamp parses a YAML timestamp without an offset in location.
+// It reports false for timestamps with an offset, which YAML already reads.
+func parseLocalTimestamp(value string, location *time.Location) (time.Time, bool) {
+	for _, layout := range localTimestampLayouts {
+		if t, err := time.ParseInLocation(layout, value, location); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// normalizeOrigins rewrites every origin as scheme://host, the form browsers
+// send in the Origin header, and rejects anything that is not an origin.
+func normalizeOrigins(origins []string) error {
+	for i, origin := range origins {
+		parsed, err := url.Parse(origin)
+		if err != nil || parsed.Scheme == "" || parsed.Host == "" || strings.Trim(parsed.Path, "/") != "" {
+			return fmt.Errorf("origins: %q is not an origin such as https://status.example.com", origin)
+		}
+		origins[i] = strings.ToLower(parsed.Scheme + "://" + parsed.Host)
+	}
+	return nil
+}
+
+// resolveBodyFile reads api.body_file into api.body. Relative paths are
+// resolved against the directory of the configuration file.
+func resolveBodyFile(service *internal.ServiceConf, baseDir string) error {
+	bodyFile := service.API.BodyFile
+	if bodyFile == "" {
+		return nil
+	}
+	if service.API.Body != "" {
+		return fmt.Errorf("service %s: api.body and api.body_file are mutually exclusive", service.Name)
+	}
+	if !filepath.IsAbs(bodyFile) {
+		bodyFile = filepath.Join(baseDir, bodyFile)
+	}
+
+	body, err := os.ReadFile(bodyFile)
+	if err != nil {
+		return fmt.Errorf("service %s: failed to read body file: %w", service.Name, err)
+	}
+	service.API.Body = string(body)
+	return nil
+}
+
+// resolveNotifiers substitutes ${VAR} references in notifier URLs, headers,
+// tokens, passwords and keys, so that secrets can stay out of config.yaml. Template
+// directories are resolved against the directory of the configuration file.
+func resolveNotifiers(notifiers []internal.NotifierConf, baseDir string) {
+	for i := range notifiers {
+		notifier := &notifiers[i]
+		notifier.URL = os.ExpandEnv(notifier.URL)
+		notifier.Token = os.ExpandEnv(notifier.Token)
+		notifier.SMTP.Password = os.ExpandEnv(notifier.SMTP.Password)
+		notifier.PagerDuty.RoutingKey = os.ExpandEnv(notifier.PagerDuty.RoutingKey)
+		for name, route := range notifier.PagerDuty.Services {
+			route.RoutingKey = os.ExpandEnv(route.RoutingKey)
+			notifier.PagerDuty.Services[name] = route
+		}
+		notifier.Opsgenie.APIKey = os.ExpandEnv(notifier.Opsgenie.APIKey)
+		for name, route := range notifier.Opsgenie.Services {
+			route.APIKey = os.ExpandEnv(route.APIKey)
+			notifier.Opsgenie.Services[name] = route
+		}
+		for key, value := range notifier.Headers {
+			notifier.Headers[key] = os.ExpandEnv(value)
+		}
+		if templates := notifier.SMTP.Templates; templates != "" && !filepath.IsAbs(templates) {
+			notifier.SMTP.Templates = filepath.Join(baseDir, templates)
+		}
+	}
 }
@@ -0,0 +1,258 @@
+package config
+
+import (
+	"int-status/internal"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadExplicitZeroOverridesDefaults(t *testing.T) {
+	path := writeConfig(t, `
+defaults:
+  retries: 2
+  retry_delay: 5s
+  flapping:
+    threshold: 4
+services:
+  - name: inherits
+    api:
+      url: http://127.0.0.1/
+  - name: opts-out
+    retries: 0
+    retry_delay: 0s
+    flapping:
+      threshold: 0
+    api:
+      url: http://127.0.0.1/
+  - name: overrides
+    retries: 5
+    failure_threshold: 3
+    api:
+      url: http://127.0.0.1/
+`)
+	conf, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	inherits, optsOut, overrides := conf.Services[0], conf.Services[1], conf.Services[2]
+	if inherits.Retries != 2 || inherits.RetryDelay != 5*time.Second || inherits.Flapping.Threshold != 4 {
+		t.Errorf("inherits = %+v, want the defaults", inherits)
+	}
+	if optsOut.Retries != 0 || optsOut.RetryDelay != 0 || optsOut.Flapping.Threshold != 0 {
+		t.Errorf("opts-out = %+v, want every setting off", optsOut)
+	}
+	if overrides.Retries != 5 || overrides.FailureThreshold != 3 || overrides.RecoveryThreshold != 1 {
+		t.Errorf("overrides = %+v, want retries 5 and failure threshold 3 with the default recovery threshold", overrides)
+	}
+}
+
+func TestLoadSLAOverridesDefaults(t *testing.T) {
+	path := writeConfig(t, `
+defaults:
+  sla: 99.5
+services:
+  - name: inherits
+    api:
+      url: http://127.0.0.1/
+  - name: opts-out
+    sla: 0
+    api:
+      url: http://127.0.0.1/
+  - name: overrides
+    sla: 99.9
+    api:
+      url: http://127.0.0.1/
+`)
+	conf, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, want := range []float64{99.5, 0, 99.9} {
+		if got := conf.Services[i].SLA; got != want {
+			t.Errorf("%s: sla = %v, want %v", conf.Services[i].Name, got, want)
+		}
+	}
+}
+
+func TestLoadSLOOverridesDefaults(t *testing.T) {
+	path := writeConfig(t, `
+defaults:
+  slo:
+    target: 99.9
+    latency: 500ms
+    window: 168h
+services:
+  - name: inherits
+    api:
+      url: http://127.0.0.1/
+  - name: opts-out
+    slo:
+      target: 0
+    api:
+      url: http://127.0.0.1/
+  - name: any-latency
+    slo:
+      latency: 0s
+    api:
+      url: http://127.0.0.1/
+  - name: overrides
+    slo:
+      target: 99
+      window: 720h
+    api:
+      url: http://127.0.0.1/
+`)
+	conf, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []internal.SLOConf{
+		{Target: 99.9, Latency: 500 * time.Millisecond, Window: 168 * time.Hour},
+		{Target: 0, Latency: 500 * time.Millisecond, Window: 168 * time.Hour},
+		{Target: 99.9, Latency: 0, Window: 168 * time.Hour},
+		{Target: 99, Latency: 500 * time.Millisecond, Window: 720 * time.Hour},
+	}
+	for i := range want {
+		if got := conf.Services[i].SLO; got != want[i] {
+			t.Errorf("%s: slo = %+v, want %+v", conf.Services[i].Name, got, want[i])
+		}
+	}
+	if conf.Services[1].SLO.Enabled() {
+		t.Error("opts-out: slo enabled, want disabled")
+	}
+}
+
+func TestLoadBuiltInDefaults(t *testing.T) {
+	path := writeConfig(t, `
+services:
+  - name: web
+    api:
+      url: http://127.0.0.1/
+`)
+	conf, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	web := conf.Services[0]
+	if web.Interval != defaultInterval || web.Timeout != defaultTimeout || web.RetryDelay != defaultRetryDelay ||
+		web.FailureThreshold != 1 || web.RecoveryThreshold != 1 || web.Flapping.Window != defaultFlapWindow {
+		t.Errorf("web = %+v, want the built-in defaults", web)
+	}
+	// The shipped config.yaml spells out the built-in retry delay.
+	shipped, err := Load(filepath.Join("..", "..", "config", "config.yaml"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if shipped.Defaults.RetryDelay != defaultRetryDelay {
+		t.Errorf("config.yaml retry_delay = %s, built-in default %s", shipped.Defaults.RetryDelay, defaultRetryDelay)
+	}
+}
+
+func TestLoadServiceList(t *testing.T) {
+	path := writeConfig(t, `
+- name: legacy
+  retries: 0
+  api:
+    url: http://127.0.0.1/
+`)
+	conf, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(conf.Services) != 1 || conf.Services[0].Interval != defaultInterval {
+		t.Fatalf("services = %+v, want one service with the default interval", conf.Services)
+	}
+}
+
+func TestLoadRejectsInvalidSettings(t *testing.T) {
+	tests := map[string]string{
+		"zero interval":           "interval: 0s",
+		"zero timeout":            "timeout: 0s",
+		"negative retries":        "retries: -1",
+		"negative retry delay":    "retry_delay: -1s",
+		"zero failure threshold":  "failure_threshold: 0",
+		"zero recovery threshold": "recovery_threshold: 0",
+		"negative threshold":      "flapping:\n      threshold: -2",
+		"zero flapping window":    "flapping:\n      threshold: 2\n      window: 0s",
+		"sla above 100":           "sla: 101",
+		"slo target 100":          "slo:\n      target: 100",
+		"slo window too long":     "slo:\n      target: 99\n      window: 2400h",
+		"zero slo window":         "slo:\n      target: 99\n      window: 0s",
+	}
+	for name, setting := range tests {
+		path := writeConfig(t, "services:\n  - name: web\n    "+setting+"\n    api:\n      url: http://127.0.0.1/\n")
+		_, err := Load(path)
+		if err == nil || !strings.Contains(err.Error(), "service web") {
+			t.Errorf("%s: Load error = %v, want an error naming the service", name, err)
+		}
+	}
+}
+
+func TestLoadAdminOrigins(t *testing.T) {
+	path := writeConfig(t, "admin:\n  password: secret\n  origins:\n    - HTTPS://Status.Example.com/\n    - http://localhost:8080\nservices: []\n")
+	conf, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"https://status.example.com", "http://localhost:8080"}
+	if strings.Join(conf.Admin.Origins, ",") != strings.Join(want, ",") {
+		t.Errorf("origins = %v, want %v", conf.Admin.Origins, want)
+	}
+
+	for _, origin := range []string{"status.example.com", "https://status.example.com/admin"} {
+		path := writeConfig(t, "admin:\n  origins:\n    - "+origin+"\nservices: []\n")
+		if _, err := Load(path); err == nil || !strings.Contains(err.Error(), "admin") {
+			t.Errorf("origin %q: Load error = %v, want an admin error", origin, err)
+		}
+	}
+}
+
+func TestLoadMaintenanceTimesInTimezone(t *testing.T) {
+	path := writeConfig(t, `timezone: Asia/Seoul
+maintenance:
+  - title: Local
+    services: [web]
+    start: 2025-03-01 22:00:00
+    end: 2025-03-02 01:00:00
+  - title: With offset
+    services: [web]
+    start: 2025-03-01T22:00:00Z
+    end: 2025-03-02T01:00:00+01:00
+  - title: Whole day
+    services: [web]
+    start: 2025-03-01
+    end: 2025-03-02
+services:
+  - name: web
+    api:
+      url: http://127.0.0.1/
+`)
+	conf, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	seoul := time.FixedZone("KST", 9*60*60)
+	want := [][2]time.Time{
+		{time.Date(2025, 3, 1, 22, 0, 0, 0, seoul), time.Date(2025, 3, 2, 1, 0, 0, 0, seoul)},
+		{time.Date(2025, 3, 1, 22, 0, 0, 0, time.UTC), time.Date(2025, 3, 2, 0, 0, 0, 0, time.UTC)},
+		{time.Date(2025, 3, 1, 0, 0, 0, 0, seoul), time.Date(2025, 3, 2, 0, 0, 0, 0, seoul)},
+	}
+	for i, window := range conf.Maintenance {
+		if !window.Start.Equal(want[i][0]) || !window.End.Equal(want[i][1]) {
+			t.Errorf("%s: %s to %s, want %s to %s", window.Title, window.Start, window.End, want[i][0], want[i][1])
+		}
+	}
+}
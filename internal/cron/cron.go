@@ -0,0 +1,175 @@
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression with the five standard fields:
+// minute, hour, day of month, month and day of week. Each field accepts "*",
+// numbers, ranges ("1-5"), lists ("1,15") and steps ("*/15", "0-30/10").
+// Day of week runs from 0 (Sunday) to 6; 7 is Sunday as well.
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+
+	// As in cron, when both day fields are restricted a day matches either. A
+	// field starting with "*", such as "*/2", does not restrict the day.
+	domAny, dowAny bool
+}
+
+// field describes the range of one field of an expression.
+type field struct {
+	name     string
+	min, max int
+}
+
+var fields = []field{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// Parse parses a cron expression such as "0 2 * * 0" (Sundays at 02:00).
+func Parse(expr string) (*Schedule, error) {
+	parts := strings.Fields(expr)
+	if len(parts) != len(fields) {
+		return nil, fmt.Errorf("cron expression %q must have %d fields, got %d", expr, len(fields), len(parts))
+	}
+
+	var bits [5]uint64
+	for i, part := range parts {
+		parsed, err := parseField(part, fields[i])
+		if err != nil {
+			return nil, fmt.Errorf("cron expression %q: %v", expr, err)
+		}
+		bits[i] = parsed
+	}
+	// Sunday may be written as 0 or 7.
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+
+	s := &Schedule{
+		minute: bits[0],
+		hour:   bits[1],
+		dom:    bits[2],
+		month:  bits[3],
+		dow:    bits[4],
+		domAny: strings.HasPrefix(parts[2], "*"),
+		dowAny: strings.HasPrefix(parts[4], "*"),
+	}
+	if !s.possible() {
+		return nil, fmt.Errorf("cron expression %q never matches: no month has the day of month", expr)
+	}
+	return s, nil
+}
+
+// daysInMonth is the longest length of every month, counting leap years.
+var daysInMonth = [13]int{0, 31, 29, 31, 30, 31, 30, 31, 31, 30, 31, 30, 31}
+
+// possible reports whether some day matches the schedule. Only a day of month
+// that none of the months has, e.g. "0 0 30 2 *", can rule out every day,
+// since a day of week comes round every month.
+func (s *Schedule) possible() bool {
+	if s.domAny || !s.dowAny {
+		return true
+	}
+	for month := 1; month <= 12; month++ {
+		if !has(s.month, month) {
+			continue
+		}
+		for day := 1; day <= daysInMonth[month]; day++ {
+			if has(s.dom, day) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// Next returns the first time after t matched by the schedule, in the location
+// of t, or the zero time if there is none within five years.
+func (s *Schedule) Next(t time.Time) time.Time {
+	location := t.Location()
+	t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, location)
+	limit := t.AddDate(5, 0, 0)
+
+	for t.Before(limit) {
+		if !has(s.month, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, location)
+			continue
+		}
+		if !s.dayMatches(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, location)
+			continue
+		}
+		if !has(s.hour, t.Hour()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, location)
+			continue
+		}
+		if !has(s.minute, t.Minute()) {
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour(), t.Minute()+1, 0, 0, location)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) dayMatches(t time.Time) bool {
+	dom := has(s.dom, t.Day())
+	dow := has(s.dow, int(t.Weekday()))
+	if s.domAny || s.dowAny {
+		return dom && dow
+	}
+	return dom || dow
+}
+
+// parseField returns the values matched by a comma separated field as bits.
+func parseField(value string, f field) (uint64, error) {
+	var bits uint64
+	for _, part := range strings.Split(value, ",") {
+		rangePart, step := part, 1
+		if slash := strings.Index(part, "/"); slash >= 0 {
+			parsed, err := strconv.Atoi(part[slash+1:])
+			if err != nil || parsed <= 0 {
+				return 0, fmt.Errorf("invalid step in %s %q", f.name, part)
+			}
+			rangePart, step = part[:slash], parsed
+		}
+
+		low, high := f.min, f.max
+		if rangePart != "*" {
+			var err error
+			bounds := strings.SplitN(rangePart, "-", 2)
+			if low, err = strconv.Atoi(bounds[0]); err != nil {
+				return 0, fmt.Errorf("invalid %s %q", f.name, part)
+			}
+			high = low
+			if len(bounds) == 2 {
+				if high, err = strconv.Atoi(bounds[1]); err != nil {
+					return 0, fmt.Errorf("invalid %s %q", f.name, part)
+				}
+			} else if step > 1 {
+				// "5/15" means from 5 to the end of the range.
+				high = f.max
+			}
+		}
+		if low < f.min || high > f.max || low > high {
+			return 0, fmt.Errorf("%s %q is out of range %d-%d", f.name, part, f.min, f.max)
+		}
+
+		for v := low; v <= high; v += step {
+			bits |= 1 << v
+		}
+	}
+	return bits, nil
+}
+
+func has(bits uint64, v int) bool {
+	return bits&(1<<v) != 0
+}
@@ -0,0 +1,90 @@
+package cron
+
+import (
+	"testing"
+	"time"
+)
+
+func TestNext(t *testing.T) {
+	// Wednesday, 1 May 2024.
+	from := time.Date(2024, 5, 1, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		expr string
+		want time.Time
+	}{
+		{"* * * * *", time.Date(2024, 5, 1, 10, 31, 0, 0, time.UTC)},
+		{"0 2 * * 0", time.Date(2024, 5, 5, 2, 0, 0, 0, time.UTC)},
+		{"0 2 * * 7", time.Date(2024, 5, 5, 2, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 5, 1, 10, 45, 0, 0, time.UTC)},
+		{"0-30/10 11 * * *", time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC)},
+		{"5/20 10 * * *", time.Date(2024, 5, 1, 10, 45, 0, 0, time.UTC)},
+		{"0 0 1,15 * *", time.Date(2024, 5, 15, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 1-3 *", time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)},
+		// With both day fields restricted, either matches: the 10th or a Friday.
+		{"0 0 10 * 5", time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC)},
+		// A day field starting with "*" does not restrict the day, so the
+		// other field must match as well: odd days that are Fridays.
+		{"0 0 */2 * 5", time.Date(2024, 5, 3, 0, 0, 0, 0, time.UTC)},
+		{"0 0 */2 * 1", time.Date(2024, 5, 13, 0, 0, 0, 0, time.UTC)},
+		{"0 0 10 * */7", time.Date(2024, 11, 10, 0, 0, 0, 0, time.UTC)},
+		// 29 February only comes round in leap years.
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, test := range tests {
+		schedule, err := Parse(test.expr)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", test.expr, err)
+			continue
+		}
+		if got := schedule.Next(from); !got.Equal(test.want) {
+			t.Errorf("%q: Next = %s, want %s", test.expr, got, test.want)
+		}
+	}
+}
+
+func TestNextKeepsLocation(t *testing.T) {
+	seoul, err := time.LoadLocation("Asia/Seoul")
+	if err != nil {
+		t.Skip(err)
+	}
+	schedule, err := Parse("0 2 * * *")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := schedule.Next(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC))
+	if want := time.Date(2024, 5, 1, 2, 0, 0, 0, time.UTC); !got.Equal(want) {
+		t.Errorf("Next in UTC = %s, want %s", got, want)
+	}
+	got = schedule.Next(time.Date(2024, 5, 1, 0, 0, 0, 0, seoul))
+	if want := time.Date(2024, 5, 1, 2, 0, 0, 0, seoul); !got.Equal(want) || got.Location() != seoul {
+		t.Errorf("Next in Seoul = %s, want %s", got, want)
+	}
+}
+
+func TestParseRejectsInvalidExpressions(t *testing.T) {
+	for _, expr := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"a * * * *",
+		"*/0 * * * *",
+		"5-1 * * * *",
+		// Days that no month has.
+		"0 0 30 2 *",
+		"0 0 31 4,6,9,11 *",
+	} {
+		if _, err := Parse(expr); err == nil {
+			t.Errorf("Parse(%q) succeeded, want an error", expr)
+		}
+	}
+
+	// With a day of week as well, the schedule matches on that day.
+	if _, err := Parse("0 0 30 2 1"); err != nil {
+		t.Errorf("Parse(%q): %v", "0 0 30 2 1", err)
+	}
+}
@@ -2,37 +2,623 @@ package internal
 
 import "time"
 
+// State is the health of a service or component at a point in time.
+type State string
+
+const (
+	// StateUp means the check succeeded.
+	StateUp State = "UP"
+	// StateDegraded means the service works but is slow or partially impaired.
+	StateDegraded State = "DEGRADED"
+	// StateDown means the service failed its check.
+	StateDown State = "DOWN"
+	// StateUnknown means the check could not tell, e.g. because the monitoring
+	// host itself had no network.
+	StateUnknown State = "UNKNOWN"
+	// StateMaintenance means the service is under planned maintenance.
+	StateMaintenance State = "MAINTENANCE"
+	// StateFlapping means the service keeps toggling between UP and DOWN.
+	// It is only ever derived, never the result of a single check.
+	StateFlapping State = "FLAPPING"
+)
+
+// Severity orders states from healthy to broken, so that the worst of several
+// states can be picked. MAINTENANCE ranks below DEGRADED because it is planned.
+func (s State) Severity() int {
+	switch s {
+	case StateUp:
+		return 0
+	case StateMaintenance:
+		return 1
+	case StateUnknown:
+		return 2
+	case StateDegraded:
+		return 3
+	case StateFlapping:
+		return 4
+	case StateDown:
+		return 5
+	default:
+		return 2
+	}
+}
+
 // Status represents the real-time status of a service.
 // @field Service   The name of the service.
 // @field Timestamp The timestamp when the status was recorded.
-// @field Status    The current status of the service (e.g., "UP", "DOWN").
+// @field Status    The state the check returned (e.g., StateUp, StateDown).
+// @field Derived   The state after failure thresholds and flap detection. Empty for statuses recorded before they existed.
 // @field Latency   The response time in milliseconds.
+// @field Assertion The name of the check that failed, if any (e.g., "status_code").
+// @field Message   Why the check failed, if it did.
+// @field ErrorClass The kind of transport error (e.g., "timeout", "dns"), if the service could not be reached.
+// @field Components Per-component statuses reported by the service, if any.
+// @field Certificate The TLS certificate presented by the service, if it was inspected.
 type Status struct {
-	Service   string
-	Timestamp time.Time
-	Status    string
-	Latency   int64
+	Service     string
+	Timestamp   time.Time
+	Status      State
+	Derived     State
+	Latency     int64
+	Assertion   string
+	Message     string
+	ErrorClass  string
+	Components  []ComponentStatus
+	Certificate *CertificateInfo
+}
+
+// State returns the derived state, or the state the check returned when there
+// is no derived one.
+func (s Status) State() State {
+	if s.Derived != "" {
+		return s.Derived
+	}
+	return s.Status
+}
+
+// Uptime is how long a service spent available and unavailable within a
+// period, according to its derived state.
+// @field Up          Time UP or DEGRADED, or FLAPPING while the check succeeded.
+// @field Down        Time DOWN, or FLAPPING while the check failed.
+// @field Maintenance Time under maintenance. It does not count towards availability.
+// @field Unknown     Time TinyPing could not tell. It does not count towards availability.
+type Uptime struct {
+	Up          time.Duration
+	Down        time.Duration
+	Maintenance time.Duration
+	Unknown     time.Duration
+}
+
+// Add counts d towards the state of status.
+func (u *Uptime) Add(status Status, d time.Duration) {
+	switch status.State() {
+	case StateMaintenance:
+		u.Maintenance += d
+	case StateUnknown:
+		u.Unknown += d
+	case StateDown:
+		u.Down += d
+	case StateFlapping:
+		if status.Status == StateDown {
+			u.Down += d
+		} else {
+			u.Up += d
+		}
+	default:
+		u.Up += d
+	}
+}
+
+// Percentage returns the share of Up in Up and Down, from 0 to 100. It
+// reports false when neither was recorded.
+func (u Uptime) Percentage() (float64, bool) {
+	total := u.Up + u.Down
+	if total <= 0 {
+		return 0, false
+	}
+	return 100 * float64(u.Up) / float64(total), true
+}
+
+// CertificateInfo summarizes the leaf certificate of a TLS endpoint.
+// @field Subject       The common name of the leaf certificate.
+// @field Issuer        The common name (or organization) of the issuer.
+// @field NotAfter      When the certificate expires.
+// @field DaysLeft      Whole days until NotAfter; negative once expired.
+// @field DNSNames      The SANs of the certificate.
+// @field HostnameValid Whether the SANs cover the checked hostname.
+// @field ChainValid    Whether the chain verifies against the system roots.
+type CertificateInfo struct {
+	Subject       string
+	Issuer        string
+	NotAfter      time.Time
+	DaysLeft      int
+	DNSNames      []string
+	HostnameValid bool
+	ChainValid    bool
+}
+
+// ComponentStatus represents the status of a single component of a service,
+// such as a Statuspage component.
+// @field Name   The name of the component.
+// @field Status The state of the component.
+type ComponentStatus struct {
+	Name   string
+	Status State
 }
 
 // Incident represents a period of service downtime.
+// @field ID        A stable identifier, derived from the service and StartTime.
 // @field Service   The name of the service that experienced the incident.
-// @field StartTime The time when the service went down.
-// @field EndTime   The time when the service recovered.
+// @field StartTime The time when the service was confirmed DOWN.
+// @field EndTime   The time when the service recovered; zero while the incident is ongoing.
+// @field Cause     The first error of the outage.
 type Incident struct {
+	ID        string
 	Service   string
 	StartTime time.Time
 	EndTime   time.Time
+	Cause     string
+}
+
+// Ongoing reports whether the service has not recovered yet.
+func (i Incident) Ongoing() bool {
+	return i.EndTime.IsZero()
+}
+
+// IncidentStatus is the stage of an incident posted by an operator, following
+// the stages vendors use on their status pages.
+type IncidentStatus string
+
+const (
+	IncidentInvestigating IncidentStatus = "investigating"
+	IncidentIdentified    IncidentStatus = "identified"
+	IncidentMonitoring    IncidentStatus = "monitoring"
+	IncidentResolved      IncidentStatus = "resolved"
+)
+
+// IncidentStatuses lists the stages in the order an incident goes through them.
+var IncidentStatuses = []IncidentStatus{IncidentInvestigating, IncidentIdentified, IncidentMonitoring, IncidentResolved}
+
+// Valid reports whether s is one of IncidentStatuses.
+func (s IncidentStatus) Valid() bool {
+	for _, status := range IncidentStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// IncidentUpdate is a message posted on a manual incident.
+// @field Timestamp When the update was posted.
+// @field Status    The stage of the incident as of this update.
+// @field Message   What operators want readers to know.
+type IncidentUpdate struct {
+	Timestamp time.Time
+	Status    IncidentStatus
+	Message   string
+}
+
+// ManualIncident is an incident created by an operator rather than detected by
+// a check, e.g. to announce a problem that health checks cannot see.
+// @field ID        A random identifier assigned on creation.
+// @field Title     A one-line summary, e.g. "Delayed payouts".
+// @field Services  The names of the affected services.
+// @field StartTime When the incident was created.
+// @field EndTime   When the incident was resolved; zero while it is ongoing.
+// @field Updates   Every update posted, oldest first. The first one is posted on creation.
+type ManualIncident struct {
+	ID        string
+	Title     string
+	Services  []string
+	StartTime time.Time
+	EndTime   time.Time
+	Updates   []IncidentUpdate
+}
+
+// Ongoing reports whether the incident has not been resolved yet.
+func (i ManualIncident) Ongoing() bool {
+	return i.EndTime.IsZero()
+}
+
+// Status returns the stage of the latest update.
+func (i ManualIncident) Status() IncidentStatus {
+	if len(i.Updates) == 0 {
+		return IncidentInvestigating
+	}
+	return i.Updates[len(i.Updates)-1].Status
+}
+
+// MaintenanceWindow is planned maintenance of some services. It is either
+// one-off, from Start to End, or recurring: it starts at every time matched by
+// Schedule and lasts Duration.
+// @field ID       A stable identifier. Windows of config.yaml are numbered "config-1", "config-2", ...
+// @field Title    What is being done, e.g. "Database upgrade".
+// @field Services The names of the services under maintenance.
+// @field Groups   The groups of services under maintenance, see ServiceConf.Group.
+// @field Start    The start of a one-off window.
+// @field End      The end of a one-off window.
+// @field Schedule A cron expression (minute hour day-of-month month day-of-week) in the configured time zone.
+// @field Duration How long each occurrence of a recurring window lasts.
+type MaintenanceWindow struct {
+	ID       string        `yaml:"-"`
+	Title    string        `yaml:"title"`
+	Services []string      `yaml:"services"`
+	Groups   []string      `yaml:"groups"`
+	Start    time.Time     `yaml:"start"`
+	End      time.Time     `yaml:"end"`
+	Schedule string        `yaml:"schedule"`
+	Duration time.Duration `yaml:"duration"`
+}
+
+// Recurring reports whether the window follows a schedule.
+func (w MaintenanceWindow) Recurring() bool {
+	return w.Schedule != ""
+}
+
+// Maintenance is one occurrence of a maintenance window.
+// @field WindowID The ID of the window.
+// @field Title    The title of the window.
+// @field Services The names of the services under maintenance, groups resolved.
+// @field Start    When the maintenance starts.
+// @field End      When the maintenance ends.
+type Maintenance struct {
+	WindowID string
+	Title    string
+	Services []string
+	Start    time.Time
+	End      time.Time
+}
+
+// Active reports whether the maintenance is in progress at t.
+func (m Maintenance) Active(t time.Time) bool {
+	return !t.Before(m.Start) && t.Before(m.End)
 }
 
 // ServiceConf represents a single service configuration.
 // @field Name        The name of the service.
 // @field Description A brief description of the service.
+// @field Type        The kind of check to run (e.g., "http", "statuspage"). Defaults to "http".
 // @field API         details for the service, including method and URL.
+// @field Assertions  Conditions a response must meet to count as UP.
+// @field TCP         details for "tcp" checks.
+// @field DNS         details for "dns" checks.
+// @field TLS         details for "tls" checks, and opt-in certificate checks for "http".
+// @field DegradedLatency A successful check slower than this is DEGRADED. Zero disables it.
+// @field Interval    How often the service is checked.
+// @field Timeout     How long a single check attempt may take.
+// @field Retries     How many times a failed check is retried before it is recorded.
+// @field RetryDelay  The pause between retries.
+// @field FailureThreshold  How many failed checks in a row make the service DOWN.
+// @field RecoveryThreshold How many successful checks in a row make a DOWN service UP again.
+// @field Flapping    When the service counts as FLAPPING.
+// @field Group       An optional group name, so that maintenance windows can cover related services at once.
+// @field SLA         The availability target in percent, e.g. 99.9. Zero means none.
+// @field SLO         The service level objective whose error budget is tracked.
+// @field Notifiers   Alert destinations for this service only, in addition to the global ones.
 type ServiceConf struct {
-	Name        string `yaml:"name"`
-	Description string `yaml:"description"`
-	API         struct {
-		Method string `yaml:"method"`
-		URL    string `yaml:"url"`
-	} `yaml:"api"`
+	Name              string         `yaml:"name"`
+	Description       string         `yaml:"description"`
+	Type              string         `yaml:"type"`
+	API               APIConf        `yaml:"api"`
+	Assertions        AssertionConf  `yaml:"assertions"`
+	TCP               TCPConf        `yaml:"tcp"`
+	DNS               DNSConf        `yaml:"dns"`
+	TLS               TLSConf        `yaml:"tls"`
+	DegradedLatency   time.Duration  `yaml:"degraded_latency"`
+	Interval          time.Duration  `yaml:"interval"`
+	Timeout           time.Duration  `yaml:"timeout"`
+	Retries           int            `yaml:"retries"`
+	RetryDelay        time.Duration  `yaml:"retry_delay"`
+	FailureThreshold  int            `yaml:"failure_threshold"`
+	RecoveryThreshold int            `yaml:"recovery_threshold"`
+	Flapping          FlappingConf   `yaml:"flapping"`
+	Group             string         `yaml:"group"`
+	SLA               float64        `yaml:"sla"`
+	SLO               SLOConf        `yaml:"slo"`
+	Notifiers         []NotifierConf `yaml:"notifiers"`
+}
+
+// Config is the root of config.yaml.
+// @field Timezone The IANA time zone used for day boundaries and displayed times.
+// @field Page     How this status page describes itself to other tools.
+// @field Defaults Values applied to every service that does not set its own.
+// @field Storage   Where check results are stored.
+// @field Notifiers Alert destinations notified about every service.
+// @field Admin     Credentials of the admin API and form.
+// @field Maintenance Planned maintenance windows.
+// @field Services  The services to monitor.
+type Config struct {
+	Timezone    string              `yaml:"timezone"`
+	Page        PageConf            `yaml:"page"`
+	Defaults    CheckDefaults       `yaml:"defaults"`
+	Storage     StorageConf         `yaml:"storage"`
+	Notifiers   []NotifierConf      `yaml:"notifiers"`
+	Admin       AdminConf           `yaml:"admin"`
+	Maintenance []MaintenanceWindow `yaml:"maintenance"`
+	Services    []ServiceConf       `yaml:"services"`
+}
+
+// AdminConf protects the admin API and form with HTTP Basic authentication.
+// The admin endpoints are disabled while Password is empty.
+// @field Username The user name. Defaults to "admin".
+// @field Password The password. ${VAR} references are expanded.
+// @field Origins  Origins, e.g. "https://status.example.com", that may send changes besides the host of the request, for proxies that rewrite the Host header.
+type AdminConf struct {
+	Username string   `yaml:"username"`
+	Password string   `yaml:"password"`
+	Origins  []string `yaml:"origins"`
+}
+
+// PageConf identifies this TinyPing instance in its Statuspage-compatible feeds.
+// @field Name The page name. Defaults to "TinyPing".
+// @field URL  The public URL of the dashboard. Defaults to the URL of the request.
+type PageConf struct {
+	Name string `yaml:"name"`
+	URL  string `yaml:"url"`
+}
+
+// StorageConf selects and configures the storage backend.
+// @field Backend  The registered backend name (e.g., "dynamodb", "sqlite"). Defaults to "dynamodb".
+// @field DynamoDB Settings for the "dynamodb" backend.
+// @field SQLite   Settings for the "sqlite" backend.
+// @field Memory   Settings for the "memory" backend.
+type StorageConf struct {
+	Backend  string       `yaml:"backend"`
+	DynamoDB DynamoDBConf `yaml:"dynamodb"`
+	SQLite   SQLiteConf   `yaml:"sqlite"`
+	Memory   MemoryConf   `yaml:"memory"`
+}
+
+// DynamoDBConf configures the DynamoDB backend.
+// @field Region The AWS region of the table.
+// @field Table  The table name (partition key "service", sort key "timestamp").
+type DynamoDBConf struct {
+	Region string `yaml:"region"`
+	Table  string `yaml:"table"`
+}
+
+// SQLiteConf configures the embedded SQLite backend.
+// @field Path The database file. Created if it does not exist.
+type SQLiteConf struct {
+	Path string `yaml:"path"`
+}
+
+// MemoryConf configures the in-memory backend.
+// @field Capacity     How many statuses are kept per service. Defaults to 1440 (a day of 1m checks).
+// @field SnapshotPath If set, statuses are saved here on shutdown and restored on startup.
+type MemoryConf struct {
+	Capacity     int    `yaml:"capacity"`
+	SnapshotPath string `yaml:"snapshot_path"`
+}
+
+// NotifierConf configures a single alert destination.
+// @field Name    A label used in logs. Defaults to the type.
+// @field Type    The registered notifier type (e.g., "webhook").
+// @field URL     The endpoint alerts are sent to.
+// @field Headers Extra request headers, e.g. Authorization.
+// @field Timeout How long a single delivery may take. Defaults to 10s.
+// @field Token   The Slack bot token. With Channel, alerts go through chat.postMessage so recoveries can thread.
+// @field Channel The Slack channel ID used with Token.
+// @field Forum   The Discord webhook posts to a forum channel; every incident gets its own thread.
+// @field SMTP      Settings for the "email" notifier.
+// @field PagerDuty Settings for the "pagerduty" notifier.
+// @field Opsgenie  Settings for the "opsgenie" notifier.
+type NotifierConf struct {
+	Name      string            `yaml:"name"`
+	Type      string            `yaml:"type"`
+	URL       string            `yaml:"url"`
+	Headers   map[string]string `yaml:"headers"`
+	Timeout   time.Duration     `yaml:"timeout"`
+	Token     string            `yaml:"token"`
+	Channel   string            `yaml:"channel"`
+	Forum     bool              `yaml:"forum"`
+	SMTP      SMTPConf          `yaml:"smtp"`
+	PagerDuty PagerDutyConf     `yaml:"pagerduty"`
+	Opsgenie  OpsgenieConf      `yaml:"opsgenie"`
+}
+
+// PagerDutyConf configures the "pagerduty" notifier (Events API v2).
+// @field RoutingKey The integration key of the PagerDuty service.
+// @field Severity   critical, error, warning or info. Defaults to critical.
+// @field Services   Per-service overrides of RoutingKey and Severity, by service name.
+type PagerDutyConf struct {
+	RoutingKey string                   `yaml:"routing_key"`
+	Severity   string                   `yaml:"severity"`
+	Services   map[string]PagerDutyConf `yaml:"services"`
+}
+
+// OpsgenieConf configures the "opsgenie" notifier (Alert API).
+// @field APIKey   The key of an Opsgenie API integration.
+// @field Priority P1 to P5. Defaults to P1.
+// @field Region   "us" or "eu". Defaults to "us".
+// @field Services Per-service overrides of APIKey and Priority, by service name.
+type OpsgenieConf struct {
+	APIKey   string                  `yaml:"api_key"`
+	Priority string                  `yaml:"priority"`
+	Region   string                  `yaml:"region"`
+	Services map[string]OpsgenieConf `yaml:"services"`
+}
+
+// SMTPConf configures the "email" notifier.
+// @field Host       The SMTP server.
+// @field Port       The SMTP port. Defaults to 465 with TLS "tls" and to 587 otherwise.
+// @field Username   The user to authenticate as. Authentication is skipped when empty.
+// @field Password   The password of Username.
+// @field TLS        "starttls" requires STARTTLS, "tls" connects with TLS, "none" never encrypts. Unset uses STARTTLS when offered.
+// @field From       The sender address.
+// @field To         Recipients of alerts about every service.
+// @field Recipients Additional recipients per service name.
+// @field Templates  A directory with subject.tmpl, body.txt.tmpl and body.html.tmpl overriding the built-in ones.
+type SMTPConf struct {
+	Host       string              `yaml:"host"`
+	Port       int                 `yaml:"port"`
+	Username   string              `yaml:"username"`
+	Password   string              `yaml:"password"`
+	TLS        string              `yaml:"tls"`
+	From       string              `yaml:"from"`
+	To         []string            `yaml:"to"`
+	Recipients map[string][]string `yaml:"recipients"`
+	Templates  string              `yaml:"templates"`
+}
+
+// CheckDefaults holds the global defaults for per-service check settings.
+// @field Interval   How often services are checked.
+// @field Timeout    How long a single check attempt may take.
+// @field Retries    How many times a failed check is retried.
+// @field RetryDelay The pause between retries.
+// @field FailureThreshold  How many failed checks in a row make a service DOWN. Defaults to 1.
+// @field RecoveryThreshold How many successful checks in a row make a DOWN service UP again. Defaults to 1.
+// @field Flapping   When a service counts as FLAPPING.
+// @field SLA        The availability target of services that do not set one.
+// @field SLO        The service level objective of services that do not set one.
+type CheckDefaults struct {
+	Interval          time.Duration `yaml:"interval"`
+	Timeout           time.Duration `yaml:"timeout"`
+	Retries           int           `yaml:"retries"`
+	RetryDelay        time.Duration `yaml:"retry_delay"`
+	FailureThreshold  int           `yaml:"failure_threshold"`
+	RecoveryThreshold int           `yaml:"recovery_threshold"`
+	Flapping          FlappingConf  `yaml:"flapping"`
+	SLA               float64       `yaml:"sla"`
+	SLO               SLOConf       `yaml:"slo"`
+}
+
+// SLOConf is a service level objective: the share of checks that must be good
+// within a rolling window. A check is bad when it fails or, with a latency
+// objective, when it is slower than Latency.
+// @field Target  The share of good checks in percent, e.g. 99.9. Zero disables the SLO.
+// @field Latency Checks slower than this count as bad. Zero leaves latency out.
+// @field Window  The rolling period of the objective and its error budget. Defaults to 30 days.
+type SLOConf struct {
+	Target  float64       `yaml:"target"`
+	Latency time.Duration `yaml:"latency"`
+	Window  time.Duration `yaml:"window"`
+}
+
+// Enabled reports whether an objective is set.
+func (c SLOConf) Enabled() bool {
+	return c.Target > 0
+}
+
+// SLOReport is the state of the SLO of a service.
+// @field Service         The name of the service.
+// @field Objective       The objective.
+// @field Good            The good checks within the window.
+// @field Bad             The bad checks within the window.
+// @field BudgetRemaining The share of the error budget left: 1 while untouched, negative once overspent.
+// @field BurnRates       How fast the budget burned over the last minutes and hours.
+// @field Burning         Whether a burn rate alert is firing.
+type SLOReport struct {
+	Service         string
+	Objective       SLOConf
+	Good            int
+	Bad             int
+	BudgetRemaining float64
+	BurnRates       []BurnRate
+	Burning         bool
+}
+
+// Availability returns the share of good checks in percent. It reports false
+// when no check was counted.
+func (r SLOReport) Availability() (float64, bool) {
+	total := r.Good + r.Bad
+	if total == 0 {
+		return 0, false
+	}
+	return 100 * float64(r.Good) / float64(total), true
+}
+
+// BurnRate is how fast the error budget burned over a period. At a rate of 1
+// the budget lasts exactly the SLO window; at 10 it is gone after a tenth.
+// @field Window The short name of the period, e.g. "1h".
+// @field Period The length of the period, ending now.
+// @field Rate   The burn rate.
+type BurnRate struct {
+	Window string
+	Period time.Duration
+	Rate   float64
+}
+
+// FlappingConf configures flap detection. A service whose checks switch
+// between success and failure more than Threshold times within Window is
+// FLAPPING, and no alerts are sent for it until it settles.
+// @field Threshold The number of switches allowed within Window. Zero disables flap detection.
+// @field Window    The period switches are counted over. Defaults to 10m.
+type FlappingConf struct {
+	Threshold int           `yaml:"threshold"`
+	Window    time.Duration `yaml:"window"`
+}
+
+// APIConf describes the HTTP request used to probe a service.
+// @field Method   The HTTP method (defaults to GET).
+// @field URL      The endpoint to call.
+// @field Headers  Extra request headers, e.g. Authorization.
+// @field Query    Query parameters merged into the URL.
+// @field Body     Inline request body.
+// @field BodyFile Path to a file whose contents are used as the request body.
+type APIConf struct {
+	Method   string            `yaml:"method"`
+	URL      string            `yaml:"url"`
+	Headers  map[string]string `yaml:"headers"`
+	Query    map[string]string `yaml:"query"`
+	Body     string            `yaml:"body"`
+	BodyFile string            `yaml:"body_file"`
+}
+
+// TCPConf describes a TCP connect check.
+// @field Address The host:port to connect to.
+// @field Send    An optional payload written after connecting.
+// @field Expect  An optional substring the server must send back (e.g., an SSH banner).
+type TCPConf struct {
+	Address string `yaml:"address"`
+	Send    string `yaml:"send"`
+	Expect  string `yaml:"expect"`
+}
+
+// DNSConf describes a DNS resolution check.
+// @field Name     The name to resolve.
+// @field Record   The record type: A, AAAA, CNAME, MX or TXT. Defaults to A.
+// @field Resolver The resolver to query as host:port. Defaults to the system resolver.
+// @field Expect   Answers that must be present, e.g. IPs or MX hosts.
+type DNSConf struct {
+	Name     string   `yaml:"name"`
+	Record   string   `yaml:"record"`
+	Resolver string   `yaml:"resolver"`
+	Expect   []string `yaml:"expect"`
+}
+
+// TLSConf describes a TLS certificate check.
+// @field Address           The host:port to connect to. Port defaults to 443.
+// @field ServerName        The hostname to verify. Defaults to the host of Address (or api.url).
+// @field Check             Inspect the certificate of an "http" check as well.
+// @field ExpiryWarningDays A certificate expiring within this many days is DEGRADED. Defaults to 14.
+type TLSConf struct {
+	Address           string `yaml:"address"`
+	ServerName        string `yaml:"server_name"`
+	Check             bool   `yaml:"check"`
+	ExpiryWarningDays int    `yaml:"expiry_warning_days"`
+}
+
+// AssertionConf describes the success conditions of an HTTP check.
+// Without any assertions, every response below 500 counts as UP.
+// @field StatusCodes     Allowed status codes or ranges (e.g., "200", "200-299", "2xx").
+// @field BodyContains    Substrings the response body must contain.
+// @field BodyNotContains Substrings the response body must not contain.
+// @field BodyRegex       A regular expression the response body must match.
+// @field JSONPath        JSONPath expressions the response body must satisfy.
+type AssertionConf struct {
+	StatusCodes     []string            `yaml:"status_codes"`
+	BodyContains    []string            `yaml:"body_contains"`
+	BodyNotContains []string            `yaml:"body_not_contains"`
+	BodyRegex       string              `yaml:"body_regex"`
+	JSONPath        []JSONPathAssertion `yaml:"json_path"`
+}
+
+// JSONPathAssertion compares the value found at Path against Equals.
+// @field Path   A JSONPath expression, e.g. "$.status.indicator".
+// @field Equals The expected value.
+type JSONPathAssertion struct {
+	Path   string      `yaml:"path"`
+	Equals interface{} `yaml:"equals"`
 }
@@ -0,0 +1,125 @@
+package manager
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"github.com/sirupsen/logrus"
+	"int-status/internal"
+	"int-status/internal/notifier"
+	"time"
+)
+
+// openIncident is a service that went DOWN and has not recovered yet.
+type openIncident struct {
+	incident  internal.Incident
+	lastError string
+}
+
+// SetDispatcher sends state transition alerts through dispatcher. It must be
+// called before StartMonitoring.
+func (m *ServiceManager) SetDispatcher(dispatcher *notifier.Dispatcher) {
+	m.dispatcher = dispatcher
+}
+
+// loadOpenIncidents picks up incidents that were still ongoing when TinyPing
+// last stopped, so that a recovery after a restart resolves them.
+func (m *ServiceManager) loadOpenIncidents() {
+	for _, checker := range m.checkers {
+		name := checker.GetTargetServiceConf().Name
+		incident, err := m.storage.GetOpenIncident(name)
+		if err != nil {
+			logrus.Errorf("Error loading open incident of %s: %v", name, err)
+			continue
+		}
+		if incident == nil {
+			continue
+		}
+		m.open[name] = openIncident{incident: *incident, lastError: incident.Cause}
+		m.states[name] = &serviceState{confirmed: internal.StateDown}
+	}
+}
+
+// trackTransition opens an incident when the derived state of a service goes
+// from UP to DOWN and resolves it when the service recovers, alerting on both.
+// UNKNOWN, MAINTENANCE and FLAPPING neither open nor resolve an incident, so
+// no alerts are sent while a service flaps.
+// It is only called from collect, so the open incidents need no lock.
+func (m *ServiceManager) trackTransition(status internal.Status) {
+	open, isOpen := m.open[status.Service]
+
+	switch status.State() {
+	case internal.StateDown:
+		// A DOWN service may already pass checks that do not yet meet the
+		// recovery threshold; only failed checks describe the outage.
+		if isFailure(status.Status) {
+			open.lastError = failureReason(status)
+		}
+		if isOpen {
+			m.open[status.Service] = open
+			return
+		}
+		open.incident = internal.Incident{
+			ID:        incidentID(status.Service, status.Timestamp),
+			Service:   status.Service,
+			StartTime: status.Timestamp,
+			Cause:     open.lastError,
+		}
+		m.open[status.Service] = open
+		m.saveIncident(open.incident)
+		m.notify(notifier.EventDown, status, open)
+	case internal.StateUp, internal.StateDegraded:
+		if !isOpen {
+			return
+		}
+		delete(m.open, status.Service)
+		open.incident.EndTime = status.Timestamp
+		m.saveIncident(open.incident)
+		m.notify(notifier.EventUp, status, open)
+	}
+}
+
+func (m *ServiceManager) saveIncident(incident internal.Incident) {
+	if err := m.storage.SaveIncident(incident); err != nil {
+		logrus.Errorf("Error saving incident %s of %s: %v", incident.ID, incident.Service, err)
+	}
+}
+
+func (m *ServiceManager) notify(eventType notifier.EventType, status internal.Status, open openIncident) {
+	if m.dispatcher == nil {
+		return
+	}
+	conf, ok := m.serviceConf(status.Service)
+	if !ok {
+		return
+	}
+
+	event := notifier.Event{
+		Type:      eventType,
+		Service:   conf,
+		Status:    status,
+		Incident:  open.incident,
+		LastError: open.lastError,
+	}
+	if !eventType.Raises() {
+		event.Duration = status.Timestamp.Sub(open.incident.StartTime)
+	}
+	m.dispatcher.Dispatch(event)
+}
+
+// incidentID derives a stable ID from the service and the start of an incident.
+func incidentID(service string, start time.Time) string {
+	sum := sha1.Sum([]byte(service + "|" + start.UTC().Format(time.RFC3339Nano)))
+	return hex.EncodeToString(sum[:8])
+}
+
+// failureReason describes why a check failed.
+func failureReason(status internal.Status) string {
+	switch {
+	case status.Message != "":
+		return status.Message
+	case status.Assertion != "":
+		return "assertion failed: " + status.Assertion
+	default:
+		return string(status.Status)
+	}
+}
@@ -0,0 +1,51 @@
+package manager
+
+import (
+	"int-status/internal"
+	"int-status/internal/notifier"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestIncidentsPersistAcrossRestart(t *testing.T) {
+	store := newMemoryStorage(t)
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+
+	first := newTestManager(t, store, internal.ServiceConf{Name: "web"})
+	first.run(checks("web", start, internal.StateUp, internal.StateDown, internal.StateDown)...)
+
+	open, err := store.GetOpenIncident("web")
+	if err != nil || open == nil {
+		t.Fatalf("open incident = %v (%v), want one", open, err)
+	}
+	down := start.Add(time.Minute)
+	if open.ID != incidentID("web", down) || !open.StartTime.Equal(down) {
+		t.Errorf("incident %s started at %s, want %s at %s", open.ID, open.StartTime, incidentID("web", down), down)
+	}
+	if got := first.recorder.types(); !slices.Equal(got, []notifier.EventType{notifier.EventDown}) {
+		t.Errorf("alerts before restart = %v, want a single down", got)
+	}
+
+	// After a restart, the outage is not alerted on again, and the recovery
+	// resolves the incident opened before.
+	second := newTestManager(t, store, internal.ServiceConf{Name: "web"})
+	second.run(checks("web", start.Add(10*time.Minute), internal.StateDown, internal.StateUp)...)
+
+	if got := second.recorder.types(); !slices.Equal(got, []notifier.EventType{notifier.EventUp}) {
+		t.Fatalf("alerts after restart = %v, want a single up", got)
+	}
+	if event := second.recorder.events[0]; event.Incident.ID != open.ID || event.Duration != 10*time.Minute {
+		t.Errorf("recovery alert for incident %s after %s, want %s after 10m", event.Incident.ID, event.Duration, open.ID)
+	}
+	incidents, err := store.GetIncidents("web", start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(incidents) != 1 || incidents[0].ID != open.ID || !incidents[0].EndTime.Equal(start.Add(11*time.Minute)) {
+		t.Errorf("incidents = %+v, want the one incident resolved", incidents)
+	}
+	if open, _ := store.GetOpenIncident("web"); open != nil {
+		t.Errorf("incident %s still open", open.ID)
+	}
+}
@@ -0,0 +1,189 @@
+package manager
+
+import (
+	"int-status/internal"
+	"int-status/internal/storage"
+	"time"
+)
+
+// historyReadOverlap is how much of the range already read is read again,
+// since statuses reach storage up to flushInterval after their check.
+const historyReadOverlap = 2 * flushInterval
+
+// historyRetention is how many ranges of buckets are kept per bucket width, so
+// that windows of the same length, e.g. "today" and "yesterday", share them.
+const historyRetention = 3
+
+// historyKey identifies the buckets of one service at one width.
+type historyKey struct {
+	service string
+	width   time.Duration
+}
+
+// historyBucket is the worst and the last status within one bucket.
+type historyBucket struct {
+	worst internal.Status
+	last  internal.Status
+}
+
+// historyBuckets are the statuses of a service downsampled into buckets of
+// equal width, aligned to the Unix epoch so that they stay valid while a
+// window moves. Statuses within [since, until] have been read.
+type historyBuckets struct {
+	width   time.Duration
+	buckets map[int64]*historyBucket
+	since   time.Time
+	until   time.Time
+}
+
+// bucketWidth returns the smallest whole second such that [from, to] spans at
+// most points buckets.
+func bucketWidth(from, to time.Time, points int) time.Duration {
+	return (to.Sub(from) / time.Duration(points-1)).Truncate(time.Second) + time.Second
+}
+
+func (h *historyBuckets) index(t time.Time) int64 {
+	return t.UnixNano() / int64(h.width)
+}
+
+func (h *historyBuckets) start(index int64) time.Time {
+	return time.Unix(0, index*int64(h.width))
+}
+
+// add keeps status if it is the worst or the last of its bucket. Adding a
+// status twice has no effect, so ranges may be read again.
+func (h *historyBuckets) add(status internal.Status) {
+	bucket, ok := h.buckets[h.index(status.Timestamp)]
+	if !ok {
+		h.buckets[h.index(status.Timestamp)] = &historyBucket{worst: status, last: status}
+		return
+	}
+	bucket.worst = worse(bucket.worst, status)
+	if !status.Timestamp.Before(bucket.last.Timestamp) {
+		bucket.last = status
+	}
+}
+
+// worse returns the status with the more severe state, or the later one of two
+// equally severe statuses, so that short outages stay visible.
+func worse(a, b internal.Status) internal.Status {
+	if a.State().Severity() != b.State().Severity() {
+		if a.State().Severity() > b.State().Severity() {
+			return a
+		}
+		return b
+	}
+	if b.Timestamp.Before(a.Timestamp) {
+		return a
+	}
+	return b
+}
+
+// readHistory adds the statuses of service within [from, to] to h.
+func (m *ServiceManager) readHistory(h *historyBuckets, service string, from, to time.Time) error {
+	statuses, err := storage.GetAllHistory(m.storage, service, from, to)
+	if err != nil {
+		return err
+	}
+	for _, status := range statuses {
+		h.add(status)
+	}
+	return nil
+}
+
+// historyBuckets returns the buckets of service at width, reading only the
+// parts of [from, to] that have not been read before. m.historyMu must be held.
+func (m *ServiceManager) historyBuckets(service string, width time.Duration, from, to, now time.Time, points int) (*historyBuckets, error) {
+	key := historyKey{service: service, width: width}
+	h, ok := m.history[key]
+	if !ok {
+		h = &historyBuckets{width: width, buckets: make(map[int64]*historyBucket)}
+		m.history[key] = h
+	}
+
+	// Buckets no window has needed for a while are forgotten.
+	if cutoff := h.index(now.Add(-historyRetention * time.Duration(points) * width)); !h.until.IsZero() && h.index(h.since) < cutoff {
+		for index := range h.buckets {
+			if index < cutoff {
+				delete(h.buckets, index)
+			}
+		}
+		h.since = h.start(cutoff)
+	}
+
+	end := to
+	if end.After(now) {
+		end = now
+	}
+	switch {
+	case h.until.IsZero() || from.After(h.until) || end.Before(h.since):
+		// Nothing read yet overlaps the range.
+		clear(h.buckets)
+		if err := m.readHistory(h, service, from, end); err != nil {
+			m.forgetHistory(key)
+			return nil, err
+		}
+		h.since, h.until = from, end
+		return h, nil
+	}
+	if from.Before(h.since) {
+		if err := m.readHistory(h, service, from, h.since); err != nil {
+			m.forgetHistory(key)
+			return nil, err
+		}
+		h.since = from
+	}
+	if end.After(h.until) {
+		if err := m.readHistory(h, service, h.until.Add(-historyReadOverlap), end); err != nil {
+			m.forgetHistory(key)
+			return nil, err
+		}
+		h.until = end
+	}
+	return h, nil
+}
+
+func (m *ServiceManager) forgetHistory(key historyKey) {
+	delete(m.history, key)
+}
+
+// summarize returns the worst status of every bucket within [from, to] and the
+// latest status. The first and the last bucket may extend beyond the range, so
+// they are read again, limited to it.
+func (m *ServiceManager) summarize(h *historyBuckets, service string, from, to time.Time) (ServiceHistory, bool, error) {
+	first, last := h.index(from), h.index(to)
+	edges := map[int64]*historyBucket{}
+	for _, index := range []int64{first, last} {
+		start, end := h.start(index), h.start(index+1).Add(-time.Nanosecond)
+		if !start.Before(from) && !end.After(to) {
+			continue
+		}
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(to) {
+			end = to
+		}
+		edge := &historyBuckets{width: h.width, buckets: make(map[int64]*historyBucket)}
+		if err := m.readHistory(edge, service, start, end); err != nil {
+			return ServiceHistory{}, false, err
+		}
+		edges[index] = edge.buckets[index]
+	}
+
+	var summary ServiceHistory
+	found := false
+	for index := first; index <= last; index++ {
+		bucket, isEdge := edges[index]
+		if !isEdge {
+			bucket = h.buckets[index]
+		}
+		if bucket == nil {
+			continue
+		}
+		summary.History = append(summary.History, bucket.worst)
+		summary.Latest = bucket.last
+		found = true
+	}
+	return summary, found, nil
+}
@@ -0,0 +1,112 @@
+package manager
+
+import (
+	"int-status/internal"
+	"int-status/internal/storage"
+	"slices"
+	"testing"
+	"time"
+)
+
+// countingStorage records the history queries made against a storage.
+type countingStorage struct {
+	storage.Storage
+	queries []storage.HistoryQuery
+}
+
+func (s *countingStorage) GetHistory(service string, query storage.HistoryQuery) (storage.HistoryPage, error) {
+	s.queries = append(s.queries, query)
+	return s.Storage.GetHistory(service, query)
+}
+
+// expectedSummary downsamples the statuses within [from, to] the slow way.
+func expectedSummary(statuses []internal.Status, from, to time.Time, width time.Duration) ServiceHistory {
+	var summary ServiceHistory
+	bucket := int64(-1)
+	for _, status := range statuses {
+		if status.Timestamp.Before(from) || status.Timestamp.After(to) {
+			continue
+		}
+		index := status.Timestamp.UnixNano() / int64(width)
+		if index != bucket {
+			summary.History = append(summary.History, status)
+			bucket = index
+		} else {
+			summary.History[len(summary.History)-1] = worse(summary.History[len(summary.History)-1], status)
+		}
+		summary.Latest = status
+	}
+	return summary
+}
+
+// sameHistory reports whether a and b hold the same statuses.
+func sameHistory(a, b ServiceHistory) bool {
+	same := func(x, y internal.Status) bool {
+		return x.Timestamp.Equal(y.Timestamp) && x.Status == y.Status
+	}
+	return slices.EqualFunc(a.History, b.History, same) && same(a.Latest, b.Latest)
+}
+
+func TestGetServiceStatusReadsOnlyNewStatuses(t *testing.T) {
+	store := &countingStorage{Storage: newMemoryStorage(t)}
+	m := newTestManager(t, store, internal.ServiceConf{Name: "web"})
+
+	// A check every 15 minutes for 8 days, with a short outage every 7 hours.
+	now := time.Now()
+	var statuses []internal.Status
+	for at := now.Add(-8 * 24 * time.Hour); at.Before(now); at = at.Add(15 * time.Minute) {
+		status := internal.Status{Service: "web", Status: internal.StateUp, Timestamp: at}
+		if at.Unix()/900%28 == 0 {
+			status.Status = internal.StateDown
+		}
+		statuses = append(statuses, status)
+	}
+	if err := store.UpdateHistory(statuses[:len(statuses)-3]); err != nil {
+		t.Fatal(err)
+	}
+
+	// The first window ends before the statuses not stored yet.
+	from, to := now.Add(-7*24*time.Hour-45*time.Minute), now.Add(-45*time.Minute)
+	width := bucketWidth(from, to, dashboardPoints)
+	got, err := m.GetServiceStatus(from, to, dashboardPoints)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := expectedSummary(statuses[:len(statuses)-3], from, to, width)
+	if len(got["web"].History) > dashboardPoints || !sameHistory(got["web"], want) {
+		t.Fatalf("first summary = %d points ending %v, want %d points ending %v",
+			len(got["web"].History), got["web"].Latest.Timestamp, len(want.History), want.Latest.Timestamp)
+	}
+
+	// Later calls read only what is new, and the edges of the range.
+	store.queries = nil
+	if err := store.UpdateHistory(statuses[len(statuses)-3:]); err != nil {
+		t.Fatal(err)
+	}
+	from, to = from.Add(45*time.Minute), to.Add(45*time.Minute)
+	got, err = m.GetServiceStatus(from, to, dashboardPoints)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = expectedSummary(statuses, from, to, width)
+	if !sameHistory(got["web"], want) {
+		t.Errorf("second summary = %d points ending %v, want %d points ending %v",
+			len(got["web"].History), got["web"].Latest.Timestamp, len(want.History), want.Latest.Timestamp)
+	}
+	for _, query := range store.queries {
+		if query.To.Sub(query.From) > width {
+			t.Errorf("read %s to %s, want at most one bucket", query.From, query.To)
+		}
+	}
+}
+
+func TestGetServiceStatusWithoutData(t *testing.T) {
+	m := newTestManager(t, newMemoryStorage(t), internal.ServiceConf{Name: "web"})
+	now := time.Now()
+	if _, err := m.GetServiceStatus(now.Add(-time.Hour), now, dashboardPoints); err == nil {
+		t.Error("GetServiceStatus succeeded without any status")
+	}
+}
+
+// dashboardPoints is the number of dots of the dashboard.
+const dashboardPoints = 30
@@ -0,0 +1,264 @@
+package manager
+
+import (
+	"errors"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"int-status/internal"
+	"int-status/internal/cron"
+	"slices"
+	"sort"
+	"time"
+)
+
+// ErrUnknownWindow is returned for a maintenance window that does not exist.
+var ErrUnknownWindow = errors.New("unknown maintenance window")
+
+// ErrInvalidWindow is returned for a maintenance window with missing or
+// invalid fields, or one that cannot be changed through the API.
+var ErrInvalidWindow = errors.New("invalid maintenance window")
+
+// defaultMaintenanceTitle is the title of windows that do not set one.
+const defaultMaintenanceTitle = "Scheduled maintenance"
+
+// maxOccurrences caps the occurrences listed per recurring window, so that a
+// schedule such as "* * * * *" cannot flood a listing.
+const maxOccurrences = 100
+
+// maintenanceWindow is a validated window and its parsed schedule.
+type maintenanceWindow struct {
+	internal.MaintenanceWindow
+	schedule   *cron.Schedule
+	configured bool
+}
+
+// SetMaintenance sets the maintenance windows of config.yaml and loads those
+// created through the API. Schedules of recurring windows are read in
+// location. It must be called before StartMonitoring.
+func (m *ServiceManager) SetMaintenance(windows []internal.MaintenanceWindow, location *time.Location) error {
+	m.location = location
+	m.windows = nil
+	for _, window := range windows {
+		parsed, err := m.parseWindow(window)
+		if err != nil {
+			return fmt.Errorf("maintenance window %s: %w", window.ID, err)
+		}
+		parsed.configured = true
+		m.windows = append(m.windows, parsed)
+	}
+
+	stored, err := m.storage.GetMaintenanceWindows()
+	if err != nil {
+		return err
+	}
+	for _, window := range stored {
+		// A stored window may refer to a service that has since been removed
+		// from config.yaml; that must not keep TinyPing from starting.
+		parsed, err := m.parseWindow(window)
+		if err != nil {
+			logrus.Errorf("Ignoring maintenance window %s: %v", window.ID, err)
+			continue
+		}
+		m.windows = append(m.windows, parsed)
+	}
+	return nil
+}
+
+// AddMaintenanceWindow validates and stores a window created through the API.
+func (m *ServiceManager) AddMaintenanceWindow(window internal.MaintenanceWindow) (internal.MaintenanceWindow, error) {
+	id, err := randomID()
+	if err != nil {
+		return internal.MaintenanceWindow{}, err
+	}
+	window.ID = id
+	parsed, err := m.parseWindow(window)
+	if err != nil {
+		return internal.MaintenanceWindow{}, err
+	}
+
+	m.windowMu.Lock()
+	defer m.windowMu.Unlock()
+	if err := m.storage.SaveMaintenanceWindow(parsed.MaintenanceWindow); err != nil {
+		return internal.MaintenanceWindow{}, err
+	}
+	m.windows = append(m.windows, parsed)
+	return parsed.MaintenanceWindow, nil
+}
+
+// DeleteMaintenanceWindow deletes a window created through the API. Windows
+// of config.yaml can only be removed from the file.
+func (m *ServiceManager) DeleteMaintenanceWindow(id string) error {
+	m.windowMu.Lock()
+	defer m.windowMu.Unlock()
+
+	for i, window := range m.windows {
+		if window.ID != id {
+			continue
+		}
+		if window.configured {
+			return fmt.Errorf("%w: %s is defined in config.yaml", ErrInvalidWindow, id)
+		}
+		if err := m.storage.DeleteMaintenanceWindow(id); err != nil {
+			return err
+		}
+		m.windows = slices.Delete(m.windows, i, i+1)
+		return nil
+	}
+	return fmt.Errorf("%w: %s", ErrUnknownWindow, id)
+}
+
+// MaintenanceWindows returns every maintenance window, those of config.yaml first.
+func (m *ServiceManager) MaintenanceWindows() []internal.MaintenanceWindow {
+	m.windowMu.RLock()
+	defer m.windowMu.RUnlock()
+
+	windows := make([]internal.MaintenanceWindow, len(m.windows))
+	for i, window := range m.windows {
+		windows[i] = window.MaintenanceWindow
+	}
+	return windows
+}
+
+// GetMaintenance returns the occurrences of every window that overlap
+// [from, to], ordered by start.
+func (m *ServiceManager) GetMaintenance(from, to time.Time) []internal.Maintenance {
+	m.windowMu.RLock()
+	defer m.windowMu.RUnlock()
+
+	var maintenance []internal.Maintenance
+	for _, window := range m.windows {
+		services := m.coveredServices(window)
+		for _, start := range window.occurrences(from, to, m.location) {
+			maintenance = append(maintenance, internal.Maintenance{
+				WindowID: window.ID,
+				Title:    window.Title,
+				Services: services,
+				Start:    start,
+				End:      window.end(start),
+			})
+		}
+	}
+	sort.SliceStable(maintenance, func(i, j int) bool {
+		return maintenance[i].Start.Before(maintenance[j].Start)
+	})
+	return maintenance
+}
+
+// underMaintenance reports whether a window covers service at t.
+func (m *ServiceManager) underMaintenance(service string, t time.Time) bool {
+	conf, ok := m.serviceConf(service)
+	if !ok {
+		return false
+	}
+
+	m.windowMu.RLock()
+	defer m.windowMu.RUnlock()
+	for _, window := range m.windows {
+		if window.covers(conf) && len(window.occurrences(t, t, m.location)) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// parseWindow validates window and parses its schedule.
+func (m *ServiceManager) parseWindow(window internal.MaintenanceWindow) (maintenanceWindow, error) {
+	if window.Title == "" {
+		window.Title = defaultMaintenanceTitle
+	}
+	if len(window.Services) == 0 && len(window.Groups) == 0 {
+		return maintenanceWindow{}, fmt.Errorf("%w: services or groups are required", ErrInvalidWindow)
+	}
+	for _, service := range window.Services {
+		if !m.hasService(service) {
+			return maintenanceWindow{}, fmt.Errorf("%w: %s", ErrUnknownService, service)
+		}
+	}
+	for _, group := range window.Groups {
+		if !m.hasGroup(group) {
+			return maintenanceWindow{}, fmt.Errorf("%w: no service is in group %s", ErrInvalidWindow, group)
+		}
+	}
+
+	parsed := maintenanceWindow{MaintenanceWindow: window}
+	if !window.Recurring() {
+		if window.Start.IsZero() || window.End.IsZero() {
+			return maintenanceWindow{}, fmt.Errorf("%w: start and end, or a schedule, are required", ErrInvalidWindow)
+		}
+		if !window.End.After(window.Start) {
+			return maintenanceWindow{}, fmt.Errorf("%w: end must be after start", ErrInvalidWindow)
+		}
+		if window.Duration != 0 {
+			return maintenanceWindow{}, fmt.Errorf("%w: duration only applies to windows with a schedule", ErrInvalidWindow)
+		}
+		return parsed, nil
+	}
+
+	if !window.Start.IsZero() || !window.End.IsZero() {
+		return maintenanceWindow{}, fmt.Errorf("%w: a window has either a schedule or a start and end", ErrInvalidWindow)
+	}
+	if window.Duration <= 0 {
+		return maintenanceWindow{}, fmt.Errorf("%w: a window with a schedule requires a duration", ErrInvalidWindow)
+	}
+	schedule, err := cron.Parse(window.Schedule)
+	if err != nil {
+		return maintenanceWindow{}, fmt.Errorf("%w: %v", ErrInvalidWindow, err)
+	}
+	parsed.schedule = schedule
+	return parsed, nil
+}
+
+func (m *ServiceManager) hasGroup(group string) bool {
+	for _, conf := range m.Services() {
+		if conf.Group == group {
+			return true
+		}
+	}
+	return false
+}
+
+// coveredServices returns the names of the services covered by window, in the
+// order of config.yaml.
+func (m *ServiceManager) coveredServices(window maintenanceWindow) []string {
+	var services []string
+	for _, conf := range m.Services() {
+		if window.covers(conf) {
+			services = append(services, conf.Name)
+		}
+	}
+	return services
+}
+
+func (w maintenanceWindow) covers(conf internal.ServiceConf) bool {
+	if slices.Contains(w.Services, conf.Name) {
+		return true
+	}
+	return conf.Group != "" && slices.Contains(w.Groups, conf.Group)
+}
+
+// occurrences returns the starts of the occurrences that overlap [from, to].
+func (w maintenanceWindow) occurrences(from, to time.Time, location *time.Location) []time.Time {
+	if w.schedule == nil {
+		if w.Start.After(to) || !w.End.After(from) {
+			return nil
+		}
+		return []time.Time{w.Start}
+	}
+
+	// Next returns starts after its argument, so the first one found ends
+	// after from.
+	var starts []time.Time
+	start := w.schedule.Next(from.Add(-w.Duration).In(location))
+	for !start.IsZero() && !start.After(to) && len(starts) < maxOccurrences {
+		starts = append(starts, start)
+		start = w.schedule.Next(start)
+	}
+	return starts
+}
+
+func (w maintenanceWindow) end(start time.Time) time.Time {
+	if w.schedule == nil {
+		return w.End
+	}
+	return start.Add(w.Duration)
+}
@@ -0,0 +1,58 @@
+package manager
+
+import (
+	"int-status/internal"
+	"int-status/internal/storage"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestMaintenanceTagging(t *testing.T) {
+	store := newMemoryStorage(t)
+	m := newTestManager(t, store, internal.ServiceConf{Name: "web", Group: "core"}, internal.ServiceConf{Name: "api"})
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	err := m.SetMaintenance([]internal.MaintenanceWindow{{
+		ID:     "upgrade",
+		Groups: []string{"core"},
+		Start:  start.Add(2 * time.Minute),
+		End:    start.Add(5 * time.Minute),
+	}}, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var statuses []internal.Status
+	for _, service := range []string{"web", "api"} {
+		statuses = append(statuses, checks(service, start,
+			internal.StateUp, internal.StateUp, internal.StateDown, internal.StateDown, internal.StateUp, internal.StateDown)...)
+	}
+	m.run(statuses...)
+
+	want := []internal.State{
+		internal.StateUp, internal.StateUp,
+		internal.StateMaintenance, internal.StateMaintenance, internal.StateMaintenance,
+		internal.StateDown,
+	}
+	if got := derived(t, store, "web"); !slices.Equal(got, want) {
+		t.Errorf("web derived states = %v, want %v", got, want)
+	}
+	// The raw result of checks under maintenance is kept.
+	if history, _ := storage.GetAllHistory(store, "web", start, start.Add(time.Hour)); history[2].Status != internal.StateDown {
+		t.Errorf("raw status under maintenance = %s, want DOWN", history[2].Status)
+	}
+	if got := derived(t, store, "api"); slices.Contains(got, internal.StateMaintenance) {
+		t.Errorf("api derived states = %v, want no maintenance outside the window's group", got)
+	}
+
+	// Only the failure after the window alerts for web.
+	var webAlerts []time.Time
+	for _, event := range m.recorder.events {
+		if event.Service.Name == "web" {
+			webAlerts = append(webAlerts, event.Status.Timestamp)
+		}
+	}
+	if len(webAlerts) != 1 || !webAlerts[0].Equal(start.Add(5*time.Minute)) {
+		t.Errorf("web alerts at %v, want one after the window", webAlerts)
+	}
+}
@@ -1,9 +1,12 @@
 package manager
 
 import (
+	"errors"
+	"fmt"
 	"github.com/sirupsen/logrus"
 	"int-status/internal"
 	"int-status/internal/monitor"
+	"int-status/internal/notifier"
 	"int-status/internal/storage"
 	"runtime"
 	"sync"
@@ -14,80 +17,296 @@ import (
 type ServiceManager struct {
 	checkers []monitor.ServiceStatusChecker
 	storage  storage.Storage
+	stop     chan struct{}
+	stopped  chan struct{}
+
+	listeners  []ResultListener
+	dispatcher *notifier.Dispatcher
+	open       map[string]openIncident
+	states     map[string]*serviceState
+
+	// incidentMu serializes changes to manual incidents.
+	incidentMu sync.Mutex
+
+	windowMu sync.RWMutex
+	windows  []maintenanceWindow
+	location *time.Location
+
+	uptimeMu sync.Mutex
+	uptime   map[string]cachedUptime
+
+	historyMu sync.Mutex
+	history   map[historyKey]*historyBuckets
+
+	sloMu   sync.Mutex
+	budgets map[string]*budgetTracker
+
+	mu     sync.RWMutex
+	latest map[string]internal.Status
 }
 
+// ResultListener is notified of every check result as soon as it is collected,
+// before it is written to storage.
+type ResultListener func(status internal.Status)
+
 // NewServiceManager initializes the ServiceManager with a list of services.
-func NewServiceManager(services []internal.ServiceConf, storage storage.Storage) *ServiceManager {
+func NewServiceManager(services []internal.ServiceConf, storage storage.Storage) (*ServiceManager, error) {
 	checkers := make([]monitor.ServiceStatusChecker, len(services))
 	for i, service := range services {
-		checkers[i] = monitor.NewServiceChecker(service)
+		checker, err := monitor.NewChecker(service)
+		if err != nil {
+			return nil, err
+		}
+		checkers[i] = checker
 	}
-	return &ServiceManager{checkers: checkers, storage: storage}
+	budgets := make(map[string]*budgetTracker)
+	for _, service := range services {
+		if service.SLO.Enabled() {
+			budgets[service.Name] = newBudgetTracker(service.SLO, service.FailureThreshold)
+		}
+	}
+	return &ServiceManager{
+		checkers: checkers,
+		storage:  storage,
+		stop:     make(chan struct{}),
+		stopped:  make(chan struct{}),
+		latest:   make(map[string]internal.Status),
+		open:     make(map[string]openIncident),
+		states:   make(map[string]*serviceState),
+		location: time.Local,
+		uptime:   make(map[string]cachedUptime),
+		history:  make(map[historyKey]*historyBuckets),
+		budgets:  budgets,
+	}, nil
 }
 
-// StartMonitoring begins periodic status checks for all services
+// AddListener registers a listener for check results. It must be called
+// before StartMonitoring.
+func (m *ServiceManager) AddListener(listener ResultListener) {
+	m.listeners = append(m.listeners, listener)
+}
+
+// flushInterval is how often collected statuses are written to storage.
+const flushInterval = 5 * time.Second
+
+// StartMonitoring begins periodic status checks for all services. Each service
+// runs on its own interval; interval is used for services that do not set one.
 func (m *ServiceManager) StartMonitoring(interval time.Duration) {
 	maxGoroutines := runtime.NumCPU()*2 + 10
 	guard := make(chan struct{}, maxGoroutines)
+	statusChannel := make(chan internal.Status)
 
-	for {
-		now := time.Now()
-		nextMinute := now.Truncate(time.Minute).Add(time.Minute)
-		time.Sleep(time.Until(nextMinute))
-
-		statusChannel := make(chan internal.Status)
-		var wg sync.WaitGroup
+	m.loadOpenIncidents()
+	m.loadBudgets()
+	for _, currentMonitor := range m.checkers {
+		go m.schedule(currentMonitor, interval, guard, statusChannel)
+	}
 
-		for _, currentMonitor := range m.checkers {
-			wg.Add(1)
+	m.collect(statusChannel)
+}
 
-			go func(monitor monitor.ServiceStatusChecker) {
-				defer wg.Done()
+// schedule runs a single checker at the top of every interval until Stop is
+// called.
+func (m *ServiceManager) schedule(checker monitor.ServiceStatusChecker, interval time.Duration, guard chan struct{}, statusChannel chan<- internal.Status) {
+	if conf := checker.GetTargetServiceConf(); conf.Interval > 0 {
+		interval = conf.Interval
+	}
 
-				guard <- struct{}{}
-				status := monitor.CheckStatus(3 * time.Second)
-				statusChannel <- status
-				<-guard
-			}(currentMonitor)
+	next := time.Now().Truncate(interval).Add(interval)
+	timer := time.NewTimer(time.Until(next))
+	defer timer.Stop()
+	for {
+		select {
+		case <-timer.C:
+		case <-m.stop:
+			return
+		}
+		next = next.Add(interval)
+		for !next.After(time.Now()) {
+			next = next.Add(interval)
 		}
 
-		go func() {
-			wg.Wait()
-			close(statusChannel)
-		}()
+		select {
+		case guard <- struct{}{}:
+		case <-m.stop:
+			return
+		}
+		status := check(checker)
+		<-guard
 
-		var statuses []internal.Status
-		for status := range statusChannel {
-			statuses = append(statuses, status)
+		// collect no longer receives once stopped.
+		select {
+		case statusChannel <- status:
+		case <-m.stop:
+			return
 		}
+		timer.Reset(time.Until(next))
+	}
+}
+
+// check runs a checker, retrying failed attempts as configured.
+func check(checker monitor.ServiceStatusChecker) internal.Status {
+	conf := checker.GetTargetServiceConf()
+
+	status := checker.CheckStatus(conf.Timeout)
+	for attempt := 1; attempt <= conf.Retries && isFailure(status.Status); attempt++ {
+		time.Sleep(conf.RetryDelay)
+		status = checker.CheckStatus(conf.Timeout)
+	}
+	return status
+}
 
+func isFailure(state internal.State) bool {
+	return state == internal.StateDown || state == internal.StateUnknown
+}
+
+// Stop ends monitoring after writing any statuses that are still pending.
+// It returns once StartMonitoring has returned.
+func (m *ServiceManager) Stop() {
+	close(m.stop)
+	<-m.stopped
+}
+
+// collect batches statuses from all schedulers and writes them to storage
+// until Stop is called.
+func (m *ServiceManager) collect(statusChannel <-chan internal.Status) {
+	defer close(m.stopped)
+
+	ticker := time.NewTicker(flushInterval)
+	defer ticker.Stop()
+
+	var statuses []internal.Status
+	flush := func() {
+		if len(statuses) == 0 {
+			return
+		}
 		err := m.storage.UpdateHistory(statuses)
 		if err != nil {
 			logrus.Errorf("Error saving statuses to storage: %v", err)
 		}
+		statuses = nil
+	}
+
+	for {
+		select {
+		case status := <-statusChannel:
+			status.Derived = m.derive(status)
+			m.setLatest(status)
+			for _, listener := range m.listeners {
+				listener(status)
+			}
+			m.trackTransition(status)
+			m.trackBudget(status)
+			statuses = append(statuses, status)
+		case <-ticker.C:
+			flush()
+		case <-m.stop:
+			flush()
+			return
+		}
 	}
 }
 
-func (m *ServiceManager) GetDailyServiceStatus() (map[string][]internal.Status, error) {
-	servicesStatusMap := make(map[string][]internal.Status)
+// ServiceHistory summarizes the statuses of a service over a time range.
+// @field Latest  The most recent status in the range.
+// @field History The statuses in the range, downsampled to at most the requested number of points.
+type ServiceHistory struct {
+	Latest  internal.Status
+	History []internal.Status
+}
+
+// ErrNoData is returned when no service has any status in the requested range.
+var ErrNoData = errors.New("no status data found")
+
+// ErrUnknownService is returned for a service that is not configured.
+var ErrUnknownService = errors.New("unknown service")
+
+// Services returns the configuration of every monitored service.
+func (m *ServiceManager) Services() []internal.ServiceConf {
+	services := make([]internal.ServiceConf, len(m.checkers))
+	for i, checker := range m.checkers {
+		services[i] = checker.GetTargetServiceConf()
+	}
+	return services
+}
+
+// GetServiceStatus returns, for every service with data in [from, to], its
+// latest status and at most points statuses summarizing the range. The
+// summaries are kept between calls, so that only statuses that are new since
+// the last call are read from storage.
+func (m *ServiceManager) GetServiceStatus(from, to time.Time, points int) (map[string]ServiceHistory, error) {
+	if points < 2 {
+		points = 2
+	}
+	width := bucketWidth(from, to, points)
+	now := time.Now()
+
+	m.historyMu.Lock()
+	defer m.historyMu.Unlock()
+
+	servicesStatusMap := make(map[string]ServiceHistory)
 	for _, checker := range m.checkers {
 		name := checker.GetTargetServiceConf().Name
-		history, err := m.storage.GetDailyHistory(name)
+		buckets, err := m.historyBuckets(name, width, from, to, now, points)
 		if err != nil {
 			return nil, err
 		}
-		servicesStatusMap[name] = history
+		summary, ok, err := m.summarize(buckets, name, from, to)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			servicesStatusMap[name] = summary
+		}
 	}
 
+	if len(servicesStatusMap) == 0 {
+		return nil, fmt.Errorf("%w between %s and %s", ErrNoData, from.Format(time.RFC3339), to.Format(time.RFC3339))
+	}
 	return servicesStatusMap, nil
 }
 
-func (m *ServiceManager) GetDailyIncidents() (map[string][]internal.Incident, error) {
+// GetLatestStatuses returns the most recent status of every service that has
+// one. Results of the running process are preferred; storage fills in the rest.
+func (m *ServiceManager) GetLatestStatuses() (map[string]internal.Status, error) {
+	latest := make(map[string]internal.Status)
+	for _, checker := range m.checkers {
+		name := checker.GetTargetServiceConf().Name
+
+		m.mu.RLock()
+		status, ok := m.latest[name]
+		m.mu.RUnlock()
+		if ok {
+			latest[name] = status
+			continue
+		}
+
+		page, err := m.storage.GetHistory(name, storage.HistoryQuery{To: time.Now(), Limit: 1})
+		if err != nil {
+			return nil, err
+		}
+		if len(page.Statuses) > 0 {
+			latest[name] = page.Statuses[len(page.Statuses)-1]
+		}
+	}
+	return latest, nil
+}
+
+// GetServiceHistory returns one page of raw statuses of a single service.
+func (m *ServiceManager) GetServiceHistory(service string, query storage.HistoryQuery) (storage.HistoryPage, error) {
+	if !m.hasService(service) {
+		return storage.HistoryPage{}, fmt.Errorf("%w: %s", ErrUnknownService, service)
+	}
+	return m.storage.GetHistory(service, query)
+}
+
+// GetIncidents returns the incidents of every service that overlap [from, to].
+func (m *ServiceManager) GetIncidents(from, to time.Time) (map[string][]internal.Incident, error) {
 	incidentsMap := make(map[string][]internal.Incident)
 
 	for _, checker := range m.checkers {
 		name := checker.GetTargetServiceConf().Name
-		incidents, err := m.storage.GetDailyIncidents(name)
+		incidents, err := m.storage.GetIncidents(name, from, to)
 		if err != nil {
 			return incidentsMap, err
 		}
@@ -98,3 +317,23 @@ func (m *ServiceManager) GetDailyIncidents() (map[string][]internal.Incident, er
 
 	return incidentsMap, nil
 }
+
+func (m *ServiceManager) setLatest(status internal.Status) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.latest[status.Service] = status
+}
+
+func (m *ServiceManager) hasService(name string) bool {
+	_, ok := m.serviceConf(name)
+	return ok
+}
+
+func (m *ServiceManager) serviceConf(name string) (internal.ServiceConf, bool) {
+	for _, checker := range m.checkers {
+		if conf := checker.GetTargetServiceConf(); conf.Name == name {
+			return conf, true
+		}
+	}
+	return internal.ServiceConf{}, false
+}
@@ -0,0 +1,178 @@
+package manager
+
+import (
+	"context"
+	"int-status/internal"
+	"int-status/internal/notifier"
+	"int-status/internal/storage"
+	"runtime"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+)
+
+// recorders holds the eventRecorder of every test by notifier name.
+var recorders sync.Map
+
+func init() {
+	notifier.Register("record", func(conf internal.NotifierConf) (notifier.Notifier, error) {
+		recorder, _ := recorders.LoadOrStore(conf.Name, &eventRecorder{})
+		return recorder.(*eventRecorder), nil
+	})
+}
+
+// eventRecorder is a notifier that keeps every event it is sent.
+type eventRecorder struct {
+	mu     sync.Mutex
+	events []notifier.Event
+}
+
+func (r *eventRecorder) Notify(ctx context.Context, event notifier.Event) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.events = append(r.events, event)
+	return nil
+}
+
+func (r *eventRecorder) types() []notifier.EventType {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	types := make([]notifier.EventType, len(r.events))
+	for i, event := range r.events {
+		types[i] = event.Type
+	}
+	return types
+}
+
+// testManager is a ServiceManager over memory storage whose alerts are
+// recorded rather than sent.
+type testManager struct {
+	*ServiceManager
+	dispatcher *notifier.Dispatcher
+	recorder   *eventRecorder
+}
+
+func newTestManager(t *testing.T, store storage.Storage, services ...internal.ServiceConf) *testManager {
+	t.Helper()
+	for i := range services {
+		if services[i].Type == "" {
+			services[i].Type = "tcp"
+			services[i].TCP = internal.TCPConf{Address: "127.0.0.1:1"}
+		}
+		if services[i].Interval == 0 {
+			services[i].Interval = time.Minute
+		}
+		if services[i].FailureThreshold == 0 {
+			services[i].FailureThreshold = 1
+		}
+		if services[i].RecoveryThreshold == 0 {
+			services[i].RecoveryThreshold = 1
+		}
+	}
+	m, err := NewServiceManager(services, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := &eventRecorder{}
+	recorders.Store(t.Name(), recorder)
+	t.Cleanup(func() { recorders.Delete(t.Name()) })
+	dispatcher, err := notifier.NewDispatcher(&internal.Config{
+		Services:  services,
+		Notifiers: []internal.NotifierConf{{Name: t.Name(), Type: "record"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.SetDispatcher(dispatcher)
+	return &testManager{ServiceManager: m, dispatcher: dispatcher, recorder: recorder}
+}
+
+// run passes statuses through collect as the schedulers would, stops the
+// manager and waits for the alerts to be delivered.
+func (m *testManager) run(statuses ...internal.Status) {
+	m.loadOpenIncidents()
+	m.loadBudgets()
+	statusChannel := make(chan internal.Status)
+	go m.collect(statusChannel)
+	for _, status := range statuses {
+		statusChannel <- status
+	}
+	m.Stop()
+	m.dispatcher.Wait()
+
+	// Deliveries run concurrently; order the alerts by the checks that caused them.
+	m.recorder.mu.Lock()
+	defer m.recorder.mu.Unlock()
+	sort.SliceStable(m.recorder.events, func(i, j int) bool {
+		return m.recorder.events[i].Status.Timestamp.Before(m.recorder.events[j].Status.Timestamp)
+	})
+}
+
+func newMemoryStorage(t *testing.T) *storage.MemoryStorage {
+	t.Helper()
+	store, err := storage.NewMemoryStorage(1000, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return store
+}
+
+// checks returns statuses of service a minute apart from start, one per state.
+func checks(service string, start time.Time, states ...internal.State) []internal.Status {
+	statuses := make([]internal.Status, len(states))
+	for i, state := range states {
+		statuses[i] = internal.Status{Service: service, Status: state, Timestamp: start.Add(time.Duration(i) * time.Minute)}
+	}
+	return statuses
+}
+
+// derived returns the derived states of the stored statuses of service.
+func derived(t *testing.T, store storage.Storage, service string) []internal.State {
+	t.Helper()
+	history, err := storage.GetAllHistory(store, service, time.Time{}, time.Now().Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	states := make([]internal.State, len(history))
+	for i, status := range history {
+		states[i] = status.Derived
+	}
+	return states
+}
+
+func TestStopEndsSchedulers(t *testing.T) {
+	store, err := storage.NewMemoryStorage(100, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	services := make([]internal.ServiceConf, 3)
+	for i := range services {
+		services[i] = internal.ServiceConf{
+			Name:     string(rune('a' + i)),
+			Type:     "tcp",
+			TCP:      internal.TCPConf{Address: "127.0.0.1:1"},
+			Interval: 10 * time.Millisecond,
+			Timeout:  100 * time.Millisecond,
+		}
+	}
+	m, err := NewServiceManager(services, store)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	before := runtime.NumGoroutine()
+	go m.StartMonitoring(time.Minute)
+	time.Sleep(100 * time.Millisecond)
+	m.Stop()
+
+	// Schedulers that were about to hand over a status must return as well.
+	deadline := time.Now().Add(2 * time.Second)
+	for runtime.NumGoroutine() > before {
+		if time.Now().After(deadline) {
+			t.Fatalf("%d goroutines left after Stop, want %d", runtime.NumGoroutine(), before)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,145 @@
+package manager
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"int-status/internal"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrUnknownIncident is returned for a manual incident that does not exist.
+var ErrUnknownIncident = errors.New("unknown incident")
+
+// ErrInvalidIncident is returned for a manual incident or update with missing
+// or invalid fields.
+var ErrInvalidIncident = errors.New("invalid incident")
+
+// ErrIncidentResolved is returned when an update is posted on a resolved incident.
+var ErrIncidentResolved = errors.New("incident is already resolved")
+
+// CreateIncident opens a manual incident on services, with update as its first
+// update. An update with the status "resolved" creates it resolved.
+func (m *ServiceManager) CreateIncident(title string, services []string, update internal.IncidentUpdate) (internal.ManualIncident, error) {
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return internal.ManualIncident{}, fmt.Errorf("%w: title is required", ErrInvalidIncident)
+	}
+	if len(services) == 0 {
+		return internal.ManualIncident{}, fmt.Errorf("%w: at least one service is required", ErrInvalidIncident)
+	}
+	var affected []string
+	for _, service := range services {
+		if !m.hasService(service) {
+			return internal.ManualIncident{}, fmt.Errorf("%w: %s", ErrUnknownService, service)
+		}
+		if !slices.Contains(affected, service) {
+			affected = append(affected, service)
+		}
+	}
+	update, err := prepareUpdate(update)
+	if err != nil {
+		return internal.ManualIncident{}, err
+	}
+
+	id, err := randomID()
+	if err != nil {
+		return internal.ManualIncident{}, err
+	}
+	incident := internal.ManualIncident{
+		ID:        id,
+		Title:     title,
+		Services:  affected,
+		StartTime: update.Timestamp,
+	}
+	incident = withUpdate(incident, update)
+
+	m.incidentMu.Lock()
+	defer m.incidentMu.Unlock()
+	if err := m.storage.SaveManualIncident(incident); err != nil {
+		return internal.ManualIncident{}, err
+	}
+	return incident, nil
+}
+
+// PostIncidentUpdate adds update to the manual incident with the ID. An update
+// with the status "resolved" resolves the incident.
+func (m *ServiceManager) PostIncidentUpdate(id string, update internal.IncidentUpdate) (internal.ManualIncident, error) {
+	update, err := prepareUpdate(update)
+	if err != nil {
+		return internal.ManualIncident{}, err
+	}
+
+	m.incidentMu.Lock()
+	defer m.incidentMu.Unlock()
+
+	incident, err := m.GetManualIncident(id)
+	if err != nil {
+		return internal.ManualIncident{}, err
+	}
+	if !incident.Ongoing() {
+		return internal.ManualIncident{}, fmt.Errorf("%w: %s", ErrIncidentResolved, id)
+	}
+
+	incident = withUpdate(incident, update)
+	if err := m.storage.SaveManualIncident(incident); err != nil {
+		return internal.ManualIncident{}, err
+	}
+	return incident, nil
+}
+
+// GetManualIncident returns the manual incident with the ID.
+func (m *ServiceManager) GetManualIncident(id string) (internal.ManualIncident, error) {
+	incident, err := m.storage.GetManualIncident(id)
+	if err != nil {
+		return internal.ManualIncident{}, err
+	}
+	if incident == nil {
+		return internal.ManualIncident{}, fmt.Errorf("%w: %s", ErrUnknownIncident, id)
+	}
+	return *incident, nil
+}
+
+// GetManualIncidents returns the manual incidents that overlap [from, to],
+// oldest first.
+func (m *ServiceManager) GetManualIncidents(from, to time.Time) ([]internal.ManualIncident, error) {
+	return m.storage.GetManualIncidents(from, to)
+}
+
+// prepareUpdate validates update and stamps it with the current time.
+func prepareUpdate(update internal.IncidentUpdate) (internal.IncidentUpdate, error) {
+	update.Message = strings.TrimSpace(update.Message)
+	if update.Status == "" {
+		update.Status = internal.IncidentInvestigating
+	}
+	if !update.Status.Valid() {
+		return update, fmt.Errorf("%w: unknown status %q", ErrInvalidIncident, update.Status)
+	}
+	if update.Message == "" {
+		return update, fmt.Errorf("%w: message is required", ErrInvalidIncident)
+	}
+	update.Timestamp = time.Now()
+	return update, nil
+}
+
+// withUpdate appends update to incident and resolves it on a "resolved" update.
+func withUpdate(incident internal.ManualIncident, update internal.IncidentUpdate) internal.ManualIncident {
+	incident.Updates = append(incident.Updates, update)
+	if update.Status == internal.IncidentResolved {
+		incident.EndTime = update.Timestamp
+	}
+	return incident
+}
+
+// randomID returns a random ID for records that, unlike detected incidents,
+// have nothing to derive a stable ID from.
+func randomID() (string, error) {
+	id := make([]byte, 8)
+	if _, err := rand.Read(id); err != nil {
+		return "", fmt.Errorf("failed to generate ID: %v", err)
+	}
+	return hex.EncodeToString(id), nil
+}
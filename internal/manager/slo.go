@@ -0,0 +1,359 @@
+package manager
+
+import (
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"int-status/internal"
+	"int-status/internal/notifier"
+	"int-status/internal/storage"
+	"time"
+)
+
+// sloBucketSize is the resolution of the checks counted towards an SLO.
+const sloBucketSize = time.Minute
+
+// sloLoadPageSize is the page size used to count stored checks at startup.
+const sloLoadPageSize = 1000
+
+// burnWindows are the periods burn rates are reported for, shortest first.
+var burnWindows = []internal.BurnRate{
+	{Window: "5m", Period: 5 * time.Minute},
+	{Window: "30m", Period: 30 * time.Minute},
+	{Window: "1h", Period: time.Hour},
+	{Window: "6h", Period: 6 * time.Hour},
+}
+
+// burnRule fires when the budget burns fast enough, over both windows, to
+// spend budget of it within long. The short window lets the alert stop soon
+// after the burn does.
+type burnRule struct {
+	long   time.Duration
+	short  time.Duration
+	budget float64
+}
+
+// burnRules are the multiwindow, multi-burn-rate alerts of the Google SRE
+// workbook: 2% of the budget spent within an hour, or 5% within six hours.
+var burnRules = []burnRule{
+	{long: time.Hour, short: 5 * time.Minute, budget: 0.02},
+	{long: 6 * time.Hour, short: 30 * time.Minute, budget: 0.05},
+}
+
+// threshold is the burn rate at which the rule fires for an SLO window. For
+// 30 days these are the workbook's 14.4 and 6.
+func (r burnRule) threshold(window time.Duration) float64 {
+	return r.budget * float64(window) / float64(r.long)
+}
+
+// minBurnChecks is the fewest bad checks within the long window of a burn
+// rule that let it fire. A single failed check can exceed the burn rate of a
+// strict objective on its own, but is not worth an alert.
+const minBurnChecks = 3
+
+type sloBucket struct {
+	index int64
+	good  int
+	bad   int
+}
+
+// sloTotal is the number of good and bad checks within the last period of
+// buckets up to the head of a tracker.
+type sloTotal struct {
+	buckets int64
+	good    int
+	bad     int
+}
+
+// budgetTracker counts the good and bad checks of one service per minute over
+// its SLO window, in a ring of buckets. Running totals are kept for every
+// period alerts and reports look at, so that none of them walks the ring.
+type budgetTracker struct {
+	conf    internal.SLOConf
+	minBad  int
+	buckets []sloBucket
+	head    int64
+	totals  map[time.Duration]*sloTotal
+	burning bool
+	alert   internal.Incident
+}
+
+// newBudgetTracker returns a tracker for an SLO of a service whose outages
+// take failureThreshold failed checks. Burn rules fire on no fewer bad checks.
+func newBudgetTracker(conf internal.SLOConf, failureThreshold int) *budgetTracker {
+	t := &budgetTracker{
+		conf:    conf,
+		minBad:  max(failureThreshold, minBurnChecks),
+		buckets: make([]sloBucket, int(conf.Window/sloBucketSize)+1),
+		totals:  make(map[time.Duration]*sloTotal),
+	}
+	periods := []time.Duration{conf.Window}
+	for _, window := range burnWindows {
+		periods = append(periods, window.Period)
+	}
+	for _, rule := range burnRules {
+		periods = append(periods, rule.long, rule.short)
+	}
+	for _, period := range periods {
+		t.totals[period] = &sloTotal{buckets: int64(period / sloBucketSize)}
+	}
+	return t
+}
+
+// classify tells whether a check is good. Checks under maintenance and checks
+// that could not tell do not count.
+func (t *budgetTracker) classify(status internal.Status) (good, counted bool) {
+	if status.State() == internal.StateMaintenance {
+		return false, false
+	}
+	switch status.Status {
+	case internal.StateUp, internal.StateDegraded:
+		slow := t.conf.Latency > 0 && time.Duration(status.Latency)*time.Millisecond > t.conf.Latency
+		return !slow, true
+	case internal.StateDown:
+		return false, true
+	default:
+		return false, false
+	}
+}
+
+// within returns the good and bad checks of total that are left once the
+// head moves on to index. It walks only the buckets that leave the period.
+func (t *budgetTracker) within(total *sloTotal, index int64) (good, bad int) {
+	if index-t.head >= total.buckets {
+		return 0, 0
+	}
+	good, bad = total.good, total.bad
+	for leaving := t.head - total.buckets + 1; leaving <= index-total.buckets; leaving++ {
+		if bucket := t.buckets[leaving%int64(len(t.buckets))]; bucket.index == leaving {
+			good -= bucket.good
+			bad -= bucket.bad
+		}
+	}
+	return good, bad
+}
+
+// advance moves the head of the tracker to index, taking the buckets that
+// leave a period out of its total.
+func (t *budgetTracker) advance(index int64) {
+	if index <= t.head {
+		return
+	}
+	for _, total := range t.totals {
+		total.good, total.bad = t.within(total, index)
+	}
+	t.head = index
+}
+
+func (t *budgetTracker) add(status internal.Status) {
+	good, counted := t.classify(status)
+	if !counted {
+		return
+	}
+
+	index := status.Timestamp.UnixNano() / int64(sloBucketSize)
+	t.advance(index)
+	if index <= t.head-int64(len(t.buckets)-1) {
+		// The check is older than the window.
+		return
+	}
+	bucket := &t.buckets[index%int64(len(t.buckets))]
+	if bucket.index != index {
+		*bucket = sloBucket{index: index}
+	}
+	if good {
+		bucket.good++
+	} else {
+		bucket.bad++
+	}
+	for _, total := range t.totals {
+		if index > t.head-total.buckets {
+			if good {
+				total.good++
+			} else {
+				total.bad++
+			}
+		}
+	}
+}
+
+// count returns the good and bad checks within period before now, which must
+// be one of the periods the tracker keeps totals for. Checks counted after now
+// are included as well.
+func (t *budgetTracker) count(now time.Time, period time.Duration) (good, bad int) {
+	return t.within(t.totals[period], now.UnixNano()/int64(sloBucketSize))
+}
+
+// burnRate is the share of bad checks within period relative to the share the
+// objective allows.
+func (t *budgetTracker) burnRate(now time.Time, period time.Duration) float64 {
+	good, bad := t.count(now, period)
+	if good+bad == 0 {
+		return 0
+	}
+	return float64(bad) / float64(good+bad) / (1 - t.conf.Target/100)
+}
+
+// firing returns a description of the first burn rule that fires at now. A
+// rule only fires once its long window holds at least minBad bad checks.
+func (t *budgetTracker) firing(now time.Time) (string, bool) {
+	for _, rule := range burnRules {
+		if _, bad := t.count(now, rule.long); bad < t.minBad {
+			continue
+		}
+		threshold := rule.threshold(t.conf.Window)
+		long, short := t.burnRate(now, rule.long), t.burnRate(now, rule.short)
+		if long >= threshold && short >= threshold {
+			return fmt.Sprintf("Burn rate %.1fx over %s and %.1fx over %s, above %.1fx; %.1f%% of the error budget left",
+				long, formatWindow(rule.long), short, formatWindow(rule.short), threshold, 100*t.budgetRemaining(now)), true
+		}
+	}
+	return "", false
+}
+
+// budgetRemaining returns the share of the error budget of the SLO window
+// that is left. The budget is the share of bad checks the objective allows.
+func (t *budgetTracker) budgetRemaining(now time.Time) float64 {
+	good, bad := t.count(now, t.conf.Window)
+	if good+bad == 0 {
+		return 1
+	}
+	budget := float64(good+bad) * (1 - t.conf.Target/100)
+	return 1 - float64(bad)/budget
+}
+
+func (t *budgetTracker) report(now time.Time) internal.SLOReport {
+	good, bad := t.count(now, t.conf.Window)
+	report := internal.SLOReport{
+		Objective:       t.conf,
+		Good:            good,
+		Bad:             bad,
+		BudgetRemaining: t.budgetRemaining(now),
+		Burning:         t.burning,
+	}
+	for _, window := range burnWindows {
+		window.Rate = t.burnRate(now, window.Period)
+		report.BurnRates = append(report.BurnRates, window)
+	}
+	return report
+}
+
+// loadBudgets counts the stored checks of every service with an SLO, so that
+// a restart does not reset its error budget. A budget that is still burning
+// too fast is alerted on again, since the alert sent before the restart is
+// not known any more and its recovery would otherwise follow no alert.
+func (m *ServiceManager) loadBudgets() {
+	now := time.Now()
+	for service, tracker := range m.budgets {
+		var latest internal.Status
+		query := storage.HistoryQuery{From: now.Add(-tracker.conf.Window), To: now, Limit: sloLoadPageSize}
+		for {
+			page, err := m.storage.GetHistory(service, query)
+			if err != nil {
+				logrus.Errorf("Error loading SLO history of %s: %v", service, err)
+				break
+			}
+			m.sloMu.Lock()
+			for _, status := range page.Statuses {
+				tracker.add(status)
+				if status.Timestamp.After(latest.Timestamp) {
+					latest = status
+				}
+			}
+			m.sloMu.Unlock()
+			if page.NextCursor == "" {
+				break
+			}
+			query.Cursor = page.NextCursor
+		}
+
+		m.sloMu.Lock()
+		reason, firing := tracker.firing(now)
+		if firing {
+			tracker.burning = true
+			tracker.alert = internal.Incident{
+				ID:        incidentID(service+"#budget", now),
+				Service:   service,
+				StartTime: now,
+				Cause:     reason,
+			}
+		}
+		alert := tracker.alert
+		m.sloMu.Unlock()
+
+		if firing {
+			logrus.Infof("%s: %s", service, reason)
+			m.notify(notifier.EventBudgetBurn, latest, openIncident{incident: alert, lastError: reason})
+		}
+	}
+}
+
+// trackBudget counts a check towards the SLO of its service and alerts when
+// the error budget starts or stops burning too fast. Like trackTransition, it
+// is only called from collect.
+func (m *ServiceManager) trackBudget(status internal.Status) {
+	m.sloMu.Lock()
+	tracker, ok := m.budgets[status.Service]
+	if !ok {
+		m.sloMu.Unlock()
+		return
+	}
+	tracker.add(status)
+	reason, firing := tracker.firing(status.Timestamp)
+
+	var eventType notifier.EventType
+	switch {
+	case firing && !tracker.burning:
+		tracker.burning = true
+		tracker.alert = internal.Incident{
+			ID:        incidentID(status.Service+"#budget", status.Timestamp),
+			Service:   status.Service,
+			StartTime: status.Timestamp,
+			Cause:     reason,
+		}
+		eventType = notifier.EventBudgetBurn
+	case !firing && tracker.burning:
+		tracker.burning = false
+		tracker.alert.EndTime = status.Timestamp
+		reason = tracker.alert.Cause
+		eventType = notifier.EventBudgetRecovered
+	}
+	alert := tracker.alert
+	m.sloMu.Unlock()
+
+	switch eventType {
+	case "":
+		return
+	case notifier.EventBudgetBurn:
+		logrus.Infof("%s: %s", status.Service, reason)
+	case notifier.EventBudgetRecovered:
+		logrus.Infof("%s: error budget no longer burning too fast", status.Service)
+	}
+	m.notify(eventType, status, openIncident{incident: alert, lastError: reason})
+}
+
+// GetSLOReports returns the state of every SLO, in the order of config.yaml.
+func (m *ServiceManager) GetSLOReports() []internal.SLOReport {
+	m.sloMu.Lock()
+	defer m.sloMu.Unlock()
+
+	now := time.Now()
+	var reports []internal.SLOReport
+	for _, conf := range m.Services() {
+		tracker, ok := m.budgets[conf.Name]
+		if !ok {
+			continue
+		}
+		report := tracker.report(now)
+		report.Service = conf.Name
+		reports = append(reports, report)
+	}
+	return reports
+}
+
+// formatWindow formats the window of a burn rule, e.g. "5m" rather than "5m0s".
+func formatWindow(d time.Duration) string {
+	if d%time.Hour == 0 {
+		return fmt.Sprintf("%dh", d/time.Hour)
+	}
+	return fmt.Sprintf("%dm", d/time.Minute)
+}
@@ -0,0 +1,189 @@
+package manager
+
+import (
+	"int-status/internal"
+	"int-status/internal/notifier"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestBudgetBurnAlerts(t *testing.T) {
+	store := newMemoryStorage(t)
+	m := newTestManager(t, store, internal.ServiceConf{
+		Name: "web",
+		SLO:  internal.SLOConf{Target: 99, Latency: time.Second, Window: 30 * 24 * time.Hour},
+	})
+
+	// An hour of fast checks, 10 minutes of slow ones and an hour of fast
+	// ones again. Slow checks spend the budget without an outage. The checks
+	// are recent, since reports cover the window up to now.
+	start := time.Now().Add(-3 * time.Hour).Truncate(time.Minute)
+	var statuses []internal.Status
+	for i := 0; i < 130; i++ {
+		status := internal.Status{Service: "web", Status: internal.StateUp, Latency: 100, Timestamp: start.Add(time.Duration(i) * time.Minute)}
+		if i >= 60 && i < 70 {
+			status.Latency = 2000
+		}
+		statuses = append(statuses, status)
+	}
+	m.run(statuses...)
+
+	events := m.recorder.events
+	if got := m.recorder.types(); !slices.Equal(got, []notifier.EventType{notifier.EventBudgetBurn, notifier.EventBudgetRecovered}) {
+		t.Fatalf("alerts = %v, want a burn and its recovery", got)
+	}
+	burn, recovered := events[0], events[1]
+	if burn.Status.Timestamp.Before(start.Add(60*time.Minute)) || burn.Status.Timestamp.After(start.Add(70*time.Minute)) {
+		t.Errorf("burn alerted at %s, want while checks were slow", burn.Status.Timestamp)
+	}
+	if !strings.Contains(burn.LastError, "Burn rate") {
+		t.Errorf("burn reason = %q", burn.LastError)
+	}
+	// The longer rule keeps firing until the slow checks leave its short window.
+	if got := recovered.Status.Timestamp; got.Before(start.Add(70*time.Minute)) || got.After(start.Add(100*time.Minute)) {
+		t.Errorf("recovery alerted at %s, want within 30 minutes of the slow checks", got)
+	}
+	if recovered.Incident.ID != burn.Incident.ID || !recovered.Incident.EndTime.Equal(recovered.Status.Timestamp) {
+		t.Errorf("recovery of alert %s (ended %s), want %s", recovered.Incident.ID, recovered.Incident.EndTime, burn.Incident.ID)
+	}
+	if open, _ := store.GetOpenIncident("web"); open != nil {
+		t.Errorf("budget burn opened incident %s", open.ID)
+	}
+
+	reports := m.GetSLOReports()
+	if len(reports) != 1 || reports[0].Bad != 10 || reports[0].Good != 120 || reports[0].Burning {
+		t.Errorf("SLO reports = %+v, want 10 bad and 120 good checks", reports)
+	}
+}
+
+func TestBudgetIgnoresBlips(t *testing.T) {
+	store := newMemoryStorage(t)
+	m := newTestManager(t, store,
+		internal.ServiceConf{Name: "blip", SLO: internal.SLOConf{Target: 99.9, Window: 30 * 24 * time.Hour}},
+		internal.ServiceConf{Name: "short", FailureThreshold: 5, SLO: internal.SLOConf{Target: 99.9, Window: 30 * 24 * time.Hour}},
+	)
+
+	// A single failed check, and four in a row where it takes five to be
+	// down, burn fast enough on their own, but are not worth an alert.
+	start := time.Now().Add(-2 * time.Hour).Truncate(time.Minute)
+	var statuses []internal.Status
+	for i := 0; i < 100; i++ {
+		blip := internal.Status{Service: "blip", Status: internal.StateUp, Timestamp: start.Add(time.Duration(i) * time.Minute)}
+		short := internal.Status{Service: "short", Status: internal.StateUp, Timestamp: blip.Timestamp}
+		if i == 90 {
+			blip.Status = internal.StateDown
+		}
+		if i >= 90 && i < 94 {
+			short.Status = internal.StateDown
+		}
+		statuses = append(statuses, blip, short)
+	}
+	m.run(statuses...)
+
+	if got := m.recorder.types(); !slices.Equal(got, []notifier.EventType{notifier.EventDown, notifier.EventUp}) {
+		t.Errorf("alerts = %v, want only the outage of the blip", got)
+	}
+	reports := m.GetSLOReports()
+	if len(reports) != 2 || reports[0].Bad != 1 || reports[1].Bad != 4 {
+		t.Errorf("SLO reports = %+v, want the failed checks counted", reports)
+	}
+}
+
+func TestBudgetBurnResentAfterRestart(t *testing.T) {
+	store := newMemoryStorage(t)
+	service := internal.ServiceConf{
+		Name: "web",
+		SLO:  internal.SLOConf{Target: 99, Latency: time.Second, Window: 30 * 24 * time.Hour},
+	}
+
+	// Two hours of checks up to now, the last ten of them slow.
+	start := time.Now().Add(-2 * time.Hour).Truncate(time.Minute)
+	var statuses []internal.Status
+	for i := 0; i < 120; i++ {
+		status := internal.Status{Service: "web", Status: internal.StateUp, Latency: 100, Timestamp: start.Add(time.Duration(i) * time.Minute)}
+		if i >= 110 {
+			status.Latency = 2000
+		}
+		statuses = append(statuses, status)
+	}
+	first := newTestManager(t, store, service)
+	first.run(statuses...)
+	if got := first.recorder.types(); !slices.Equal(got, []notifier.EventType{notifier.EventBudgetBurn}) {
+		t.Fatalf("alerts before restart = %v, want a burn", got)
+	}
+
+	// The budget still burns after a restart, so it is alerted on again, and
+	// the recovery resolves that alert.
+	var fast []internal.Status
+	for i := 120; i < 160; i++ {
+		fast = append(fast, internal.Status{Service: "web", Status: internal.StateUp, Latency: 100, Timestamp: start.Add(time.Duration(i) * time.Minute)})
+	}
+	second := newTestManager(t, store, service)
+	second.run(fast...)
+
+	if got := second.recorder.types(); !slices.Equal(got, []notifier.EventType{notifier.EventBudgetBurn, notifier.EventBudgetRecovered}) {
+		t.Fatalf("alerts after restart = %v, want a burn and its recovery", got)
+	}
+	burn, recovered := second.recorder.events[0], second.recorder.events[1]
+	if !burn.Status.Timestamp.Equal(statuses[119].Timestamp) || !strings.Contains(burn.LastError, "Burn rate") {
+		t.Errorf("burn alerted for the check at %s (%q), want the last stored check", burn.Status.Timestamp, burn.LastError)
+	}
+	if recovered.Incident.ID != burn.Incident.ID {
+		t.Errorf("recovery of alert %s, want %s", recovered.Incident.ID, burn.Incident.ID)
+	}
+}
+
+func TestBudgetTrackerTotals(t *testing.T) {
+	tracker := newBudgetTracker(internal.SLOConf{Target: 99, Latency: time.Second, Window: 24 * time.Hour}, 1)
+
+	// Checks every 37 seconds with hour-long gaps, some of them late and some
+	// of them slow, compared against counting every check added.
+	base := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	var added []internal.Status
+	var head time.Time
+	compare := func(now time.Time) {
+		t.Helper()
+		last := now.UnixNano() / int64(sloBucketSize)
+		for period := range tracker.totals {
+			var wantGood, wantBad int
+			for _, status := range added {
+				index := status.Timestamp.UnixNano() / int64(sloBucketSize)
+				if index <= last-int64(period/sloBucketSize) || index > last {
+					continue
+				}
+				if status.Latency > 1000 {
+					wantBad++
+				} else {
+					wantGood++
+				}
+			}
+			if good, bad := tracker.count(now, period); good != wantGood || bad != wantBad {
+				t.Fatalf("at %s over %s: %d good and %d bad, want %d and %d", now, period, good, bad, wantGood, wantBad)
+			}
+		}
+	}
+	for i := 0; i < 6000; i++ {
+		if i%1000 < 100 {
+			continue
+		}
+		status := internal.Status{Service: "web", Status: internal.StateUp, Latency: 100, Timestamp: base.Add(time.Duration(i) * 37 * time.Second)}
+		if i%7 == 0 {
+			status.Timestamp = status.Timestamp.Add(-90 * time.Second)
+		}
+		if i%11 < 3 {
+			status.Latency = 2000
+		}
+		tracker.add(status)
+		added = append(added, status)
+		if status.Timestamp.After(head) {
+			head = status.Timestamp
+		}
+		if i%50 == 0 {
+			compare(head)
+		}
+	}
+	compare(head.Add(3 * time.Hour))
+	compare(head.Add(48 * time.Hour))
+}
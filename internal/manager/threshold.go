@@ -0,0 +1,92 @@
+package manager
+
+import (
+	"int-status/internal"
+	"time"
+)
+
+// serviceState is what derive remembers about a service between checks.
+type serviceState struct {
+	confirmed internal.State
+	failures  int
+	successes int
+
+	checked    bool
+	lastFailed bool
+	switches   []time.Time
+}
+
+// derive returns the state of a service once maintenance windows, the failure
+// and recovery thresholds and flap detection are applied to its latest check.
+// Like trackTransition, it is only called from collect.
+func (m *ServiceManager) derive(status internal.Status) internal.State {
+	conf, _ := m.serviceConf(status.Service)
+	state, ok := m.states[status.Service]
+	if !ok {
+		state = &serviceState{}
+		m.states[status.Service] = state
+	}
+
+	result := status.Status
+	if m.underMaintenance(status.Service, status.Timestamp) {
+		result = internal.StateMaintenance
+	}
+
+	switch result {
+	case internal.StateDown:
+		state.failures++
+		state.successes = 0
+		state.recordSwitch(true, status.Timestamp)
+		if state.failures >= conf.FailureThreshold {
+			state.confirmed = internal.StateDown
+		} else if state.confirmed == "" || state.confirmed == internal.StateMaintenance {
+			// Nothing is known yet, or no longer once maintenance has ended.
+			state.confirmed = internal.StateUnknown
+		}
+	case internal.StateUp, internal.StateDegraded:
+		state.successes++
+		state.failures = 0
+		state.recordSwitch(false, status.Timestamp)
+		if state.confirmed != internal.StateDown || state.successes >= conf.RecoveryThreshold {
+			state.confirmed = status.Status
+		}
+	case internal.StateMaintenance:
+		// Failures during maintenance are expected; counting starts over afterwards.
+		state.failures, state.successes = 0, 0
+		state.checked, state.switches = false, nil
+		state.confirmed = internal.StateMaintenance
+	default:
+		// A check that could not tell neither confirms nor ends an outage.
+		if state.confirmed != internal.StateDown {
+			state.confirmed = internal.StateUnknown
+		}
+	}
+
+	if state.flapping(conf.Flapping, status.Timestamp) {
+		return internal.StateFlapping
+	}
+	return state.confirmed
+}
+
+// recordSwitch remembers when checks switch between success and failure.
+func (s *serviceState) recordSwitch(failed bool, at time.Time) {
+	if s.checked && failed != s.lastFailed {
+		s.switches = append(s.switches, at)
+	}
+	s.checked = true
+	s.lastFailed = failed
+}
+
+// flapping reports whether there were more switches than allowed within the
+// window ending at now, and forgets older ones.
+func (s *serviceState) flapping(conf internal.FlappingConf, now time.Time) bool {
+	cutoff := now.Add(-conf.Window)
+	recent := s.switches[:0]
+	for _, at := range s.switches {
+		if at.After(cutoff) {
+			recent = append(recent, at)
+		}
+	}
+	s.switches = recent
+	return conf.Threshold > 0 && len(s.switches) > conf.Threshold
+}
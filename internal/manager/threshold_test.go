@@ -0,0 +1,110 @@
+package manager
+
+import (
+	"int-status/internal"
+	"int-status/internal/notifier"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestDeriveAfterMaintenance(t *testing.T) {
+	m := newTestManager(t, newMemoryStorage(t), internal.ServiceConf{Name: "web", FailureThreshold: 3})
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	err := m.SetMaintenance([]internal.MaintenanceWindow{{
+		ID:       "deploy",
+		Services: []string{"web"},
+		Start:    start,
+		End:      start.Add(10 * time.Minute),
+	}}, time.UTC)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	steps := []struct {
+		minute int
+		status internal.State
+		want   internal.State
+	}{
+		{minute: 5, status: internal.StateDown, want: internal.StateMaintenance},
+		// Once the window has ended, failures below the threshold are not
+		// confirmed yet and must not be reported as maintenance.
+		{minute: 11, status: internal.StateDown, want: internal.StateUnknown},
+		{minute: 12, status: internal.StateDown, want: internal.StateUnknown},
+		{minute: 13, status: internal.StateDown, want: internal.StateDown},
+		{minute: 14, status: internal.StateUp, want: internal.StateUp},
+	}
+	for _, step := range steps {
+		status := internal.Status{
+			Service:   "web",
+			Status:    step.status,
+			Timestamp: start.Add(time.Duration(step.minute) * time.Minute),
+		}
+		if got := m.derive(status); got != step.want {
+			t.Errorf("minute %d: derive(%s) = %s, want %s", step.minute, step.status, got, step.want)
+		}
+	}
+}
+
+func TestFailureAndRecoveryThresholds(t *testing.T) {
+	store := newMemoryStorage(t)
+	m := newTestManager(t, store, internal.ServiceConf{Name: "web", FailureThreshold: 3, RecoveryThreshold: 2})
+
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	statuses := checks("web", start,
+		internal.StateUp, internal.StateDown, internal.StateDown, internal.StateUp,
+		internal.StateDown, internal.StateDown, internal.StateDown, internal.StateUp, internal.StateUnknown, internal.StateUp)
+	statuses[6].Message = "connection refused"
+	statuses[8].Message = "i/o timeout"
+	m.run(statuses...)
+
+	want := []internal.State{
+		internal.StateUp, internal.StateUp, internal.StateUp, internal.StateUp,
+		// The third failure in a row confirms the outage.
+		internal.StateUp, internal.StateUp, internal.StateDown,
+		// UNKNOWN neither confirms nor ends the outage, nor does it break a run
+		// of successes towards the recovery threshold.
+		internal.StateDown, internal.StateDown, internal.StateUp,
+	}
+	if got := derived(t, store, "web"); !slices.Equal(got, want) {
+		t.Errorf("derived states = %v, want %v", got, want)
+	}
+	if got := m.recorder.types(); !slices.Equal(got, []notifier.EventType{notifier.EventDown, notifier.EventUp}) {
+		t.Fatalf("alerts = %v, want down and up", got)
+	}
+	if event := m.recorder.events[1]; event.Duration != 3*time.Minute || event.LastError != "i/o timeout" {
+		t.Errorf("recovery alert: duration %s, last error %q", event.Duration, event.LastError)
+	}
+}
+
+func TestFlappingSilencesAlerts(t *testing.T) {
+	store := newMemoryStorage(t)
+	m := newTestManager(t, store, internal.ServiceConf{
+		Name:     "web",
+		Flapping: internal.FlappingConf{Threshold: 2, Window: 10 * time.Minute},
+	})
+
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	statuses := checks("web", start,
+		internal.StateDown, internal.StateUp, internal.StateDown, internal.StateUp, internal.StateDown, internal.StateUp)
+	// Once the switches have left the window, the service is UP again.
+	statuses = append(statuses, checks("web", start.Add(20*time.Minute), internal.StateUp)...)
+	m.run(statuses...)
+
+	want := []internal.State{
+		internal.StateDown, internal.StateUp, internal.StateDown,
+		internal.StateFlapping, internal.StateFlapping, internal.StateFlapping,
+		internal.StateUp,
+	}
+	if got := derived(t, store, "web"); !slices.Equal(got, want) {
+		t.Errorf("derived states = %v, want %v", got, want)
+	}
+	// The outage open when flapping began is resolved only after it ends.
+	alerts := []notifier.EventType{notifier.EventDown, notifier.EventUp, notifier.EventDown, notifier.EventUp}
+	if got := m.recorder.types(); !slices.Equal(got, alerts) {
+		t.Errorf("alerts = %v, want %v", got, alerts)
+	}
+	if got := m.recorder.events[3].Status.Timestamp; !got.Equal(start.Add(20 * time.Minute)) {
+		t.Errorf("recovery alert at %s, want after flapping ended", got)
+	}
+}
@@ -0,0 +1,99 @@
+package manager
+
+import (
+	"fmt"
+	"int-status/internal"
+	"time"
+)
+
+// uptimeCacheTTL is how long computed uptime is reused. The longer windows
+// span months of statuses, and a minute more or less does not move them.
+const uptimeCacheTTL = time.Minute
+
+// uptimeMaxGap is how many check intervals a single status may cover. Longer
+// gaps, e.g. while TinyPing was stopped, count neither as up nor as down.
+const uptimeMaxGap = 2
+
+// UptimeWindow is a rolling period that uptime is reported for.
+// @field Key    The short name, e.g. "30d".
+// @field Period The length of the window, ending now.
+type UptimeWindow struct {
+	Key    string
+	Period time.Duration
+}
+
+// UptimeWindows are the windows reported on the dashboard and by the API.
+var UptimeWindows = []UptimeWindow{
+	{"24h", 24 * time.Hour},
+	{"7d", 7 * 24 * time.Hour},
+	{"30d", 30 * 24 * time.Hour},
+	{"90d", 90 * 24 * time.Hour},
+}
+
+// ServiceUptime is the availability of a service over one window.
+// @field Window     The window.
+// @field Uptime     How long the service was up and down within the window.
+// @field Percentage The availability in percent. Only meaningful when HasData is set.
+// @field HasData    Whether the service was up or down at all within the window.
+// @field Target     The SLA of the service in percent, or zero.
+// @field Breached   Whether Percentage is below Target.
+type ServiceUptime struct {
+	Window     UptimeWindow
+	Uptime     internal.Uptime
+	Percentage float64
+	HasData    bool
+	Target     float64
+	Breached   bool
+}
+
+type cachedUptime struct {
+	computed time.Time
+	windows  []ServiceUptime
+}
+
+// GetUptime returns the availability of a service over every UptimeWindow.
+// Each status counts for the time until the next check, and time under
+// maintenance does not count at all.
+func (m *ServiceManager) GetUptime(service string) ([]ServiceUptime, error) {
+	conf, ok := m.serviceConf(service)
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownService, service)
+	}
+
+	m.uptimeMu.Lock()
+	cached, ok := m.uptime[service]
+	m.uptimeMu.Unlock()
+	if ok && time.Since(cached.computed) < uptimeCacheTTL {
+		return cached.windows, nil
+	}
+
+	// The windows all end now, so one scan of the longest fills every one.
+	now := time.Now()
+	froms := make([]time.Time, len(UptimeWindows))
+	for i, window := range UptimeWindows {
+		froms[i] = now.Add(-window.Period)
+	}
+	uptimes, err := m.storage.GetUptime(service, froms, now, uptimeMaxGap*conf.Interval)
+	if err != nil {
+		return nil, err
+	}
+
+	windows := make([]ServiceUptime, len(UptimeWindows))
+	for i, window := range UptimeWindows {
+		uptime := uptimes[i]
+		percentage, hasData := uptime.Percentage()
+		windows[i] = ServiceUptime{
+			Window:     window,
+			Uptime:     uptime,
+			Percentage: percentage,
+			HasData:    hasData,
+			Target:     conf.SLA,
+			Breached:   hasData && conf.SLA > 0 && percentage < conf.SLA,
+		}
+	}
+
+	m.uptimeMu.Lock()
+	m.uptime[service] = cachedUptime{computed: now, windows: windows}
+	m.uptimeMu.Unlock()
+	return windows, nil
+}
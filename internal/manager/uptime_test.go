@@ -0,0 +1,51 @@
+package manager
+
+import (
+	"int-status/internal"
+	"testing"
+	"time"
+)
+
+func TestGetUptimeWindows(t *testing.T) {
+	store := newMemoryStorage(t)
+	m := newTestManager(t, store, internal.ServiceConf{Name: "web", Interval: 24 * time.Hour, SLA: 99.9})
+
+	// A check a day for 100 days: UP for the last 19 days, DOWN before.
+	day := 24 * time.Hour
+	now := time.Now()
+	var statuses []internal.Status
+	for k := 100; k >= 0; k-- {
+		status := internal.Status{Service: "web", Status: internal.StateUp, Timestamp: now.Add(-time.Duration(k) * day)}
+		if k >= 20 {
+			status.Status = internal.StateDown
+		}
+		statuses = append(statuses, status)
+	}
+	if err := store.UpdateHistory(statuses); err != nil {
+		t.Fatal(err)
+	}
+
+	windows, err := m.GetUptime("web")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]struct{ up, down time.Duration }{
+		"24h": {day, 0},
+		"7d":  {7 * day, 0},
+		"30d": {19 * day, 11 * day},
+		"90d": {19 * day, 71 * day},
+	}
+	if len(windows) != len(UptimeWindows) {
+		t.Fatalf("got %d windows, want %d", len(windows), len(UptimeWindows))
+	}
+	for _, window := range windows {
+		expected := want[window.Window.Key]
+		up, down := window.Uptime.Up.Round(time.Minute), window.Uptime.Down.Round(time.Minute)
+		if up != expected.up || down != expected.down {
+			t.Errorf("%s: up %s, down %s, want up %s, down %s", window.Window.Key, up, down, expected.up, expected.down)
+		}
+		if !window.HasData || window.Breached != (expected.down > 0) {
+			t.Errorf("%s: HasData %v, Breached %v", window.Window.Key, window.HasData, window.Breached)
+		}
+	}
+}
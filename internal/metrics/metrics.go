@@ -0,0 +1,212 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"int-status/internal"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const namespace = "tinyping"
+
+// states lists every state so that exactly one tinyping_service_state series
+// per service is 1 and the others are 0.
+var states = []internal.State{
+	internal.StateUp,
+	internal.StateDegraded,
+	internal.StateDown,
+	internal.StateUnknown,
+	internal.StateMaintenance,
+	internal.StateFlapping,
+}
+
+// Metrics exposes check results in the Prometheus format. It is fed directly
+// from the ServiceManager rather than from storage.
+type Metrics struct {
+	registry *prometheus.Registry
+
+	up        *prometheus.GaugeVec
+	state     *prometheus.GaugeVec
+	latency   *prometheus.GaugeVec
+	duration  *prometheus.HistogramVec
+	checks    *prometheus.CounterVec
+	failures  *prometheus.CounterVec
+	lastCheck *prometheus.GaugeVec
+
+	mu          sync.Mutex
+	started     time.Time
+	lastSuccess map[string]time.Time
+	sinceDesc   *prometheus.Desc
+
+	sloReports  func() []internal.SLOReport
+	budgetDesc  *prometheus.Desc
+	burnDesc    *prometheus.Desc
+	burningDesc *prometheus.Desc
+}
+
+// New creates the metrics and registers them on a dedicated registry.
+func New() *Metrics {
+	m := &Metrics{
+		registry: prometheus.NewRegistry(),
+		up: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "service_up",
+			Help:      "Whether the last check of the service succeeded (UP or DEGRADED).",
+		}, []string{"service"}),
+		state: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "service_state",
+			Help:      "The derived state of the service; 1 for the current state.",
+		}, []string{"service", "state"}),
+		latency: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "check_latency_seconds",
+			Help:      "Latency of the last check of the service.",
+		}, []string{"service"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Name:      "check_duration_seconds",
+			Help:      "Latency of checks of the service.",
+			Buckets:   []float64{.01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		}, []string{"service"}),
+		checks: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "checks_total",
+			Help:      "Number of checks of the service.",
+		}, []string{"service"}),
+		failures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: namespace,
+			Name:      "check_failures_total",
+			Help:      "Number of failed checks of the service by error class.",
+		}, []string{"service", "error_class"}),
+		lastCheck: prometheus.NewGaugeVec(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Name:      "last_check_timestamp_seconds",
+			Help:      "Unix time of the last check of the service.",
+		}, []string{"service"}),
+		started:     time.Now(),
+		lastSuccess: make(map[string]time.Time),
+		sinceDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "", "seconds_since_last_success"),
+			"Seconds since the last successful check of the service, or since TinyPing started if there was none.",
+			[]string{"service"}, nil,
+		),
+		budgetDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "slo", "error_budget_remaining_ratio"),
+			"Share of the error budget of the SLO window that is left; negative once overspent.",
+			[]string{"service"}, nil,
+		),
+		burnDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "slo", "burn_rate"),
+			"Rate at which the error budget burned over the window; 1 spends it exactly over the SLO window.",
+			[]string{"service", "window"}, nil,
+		),
+		burningDesc: prometheus.NewDesc(
+			prometheus.BuildFQName(namespace, "slo", "burning"),
+			"Whether a burn rate alert of the SLO is firing.",
+			[]string{"service"}, nil,
+		),
+	}
+
+	m.registry.MustRegister(
+		m.up, m.state, m.latency, m.duration, m.checks, m.failures, m.lastCheck,
+		m,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return m
+}
+
+// Observe records a single check result.
+func (m *Metrics) Observe(status internal.Status) {
+	service := status.Service
+	latency := float64(status.Latency) / 1000
+
+	m.checks.WithLabelValues(service).Inc()
+	m.latency.WithLabelValues(service).Set(latency)
+	m.duration.WithLabelValues(service).Observe(latency)
+	m.lastCheck.WithLabelValues(service).Set(float64(status.Timestamp.Unix()))
+	for _, state := range states {
+		m.state.WithLabelValues(service, string(state)).Set(boolToFloat(state == status.State()))
+	}
+
+	success := status.Status == internal.StateUp || status.Status == internal.StateDegraded
+	m.up.WithLabelValues(service).Set(boolToFloat(success))
+	m.mu.Lock()
+	if success {
+		m.lastSuccess[service] = status.Timestamp
+	} else if _, ok := m.lastSuccess[service]; !ok {
+		// A service that has not been UP yet has been failing since TinyPing
+		// started, so that alerts on this metric fire for it as well.
+		m.lastSuccess[service] = m.started
+	}
+	m.mu.Unlock()
+	if success {
+		return
+	}
+
+	if status.Status == internal.StateDown || status.Status == internal.StateUnknown {
+		m.failures.WithLabelValues(service, errorClass(status)).Inc()
+	}
+}
+
+// SetSLOReports sets the function the SLO metrics are read from at scrape
+// time. It must be called before the metrics are served.
+func (m *Metrics) SetSLOReports(reports func() []internal.SLOReport) {
+	m.sloReports = reports
+}
+
+// Handler serves the metrics in the Prometheus exposition format.
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+// Describe implements prometheus.Collector for seconds_since_last_success
+// and the SLO metrics.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	ch <- m.sinceDesc
+	ch <- m.budgetDesc
+	ch <- m.burnDesc
+	ch <- m.burningDesc
+}
+
+// Collect implements prometheus.Collector. The values are computed at scrape
+// time so that they keep changing while a service stays down.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	m.mu.Lock()
+	now := time.Now()
+	for service, last := range m.lastSuccess {
+		ch <- prometheus.MustNewConstMetric(m.sinceDesc, prometheus.GaugeValue, now.Sub(last).Seconds(), service)
+	}
+	m.mu.Unlock()
+
+	if m.sloReports == nil {
+		return
+	}
+	for _, report := range m.sloReports() {
+		ch <- prometheus.MustNewConstMetric(m.budgetDesc, prometheus.GaugeValue, report.BudgetRemaining, report.Service)
+		for _, rate := range report.BurnRates {
+			ch <- prometheus.MustNewConstMetric(m.burnDesc, prometheus.GaugeValue, rate.Rate, report.Service, rate.Window)
+		}
+		ch <- prometheus.MustNewConstMetric(m.burningDesc, prometheus.GaugeValue, boolToFloat(report.Burning), report.Service)
+	}
+}
+
+// errorClass is the transport error class, or "assertion" when the service
+// answered but failed one of its assertions.
+func errorClass(status internal.Status) string {
+	if status.ErrorClass != "" {
+		return status.ErrorClass
+	}
+	return "assertion"
+}
+
+func boolToFloat(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,105 @@
+package metrics
+
+import (
+	"bufio"
+	"int-status/internal"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// scrape returns the value of every series of m, keyed by the series name
+// with its labels, e.g. `tinyping_service_up{service="web"}`.
+func scrape(t *testing.T, m *Metrics) map[string]float64 {
+	t.Helper()
+	recorder := httptest.NewRecorder()
+	m.Handler().ServeHTTP(recorder, httptest.NewRequest("GET", "/metrics", nil))
+
+	series := make(map[string]float64)
+	scanner := bufio.NewScanner(recorder.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+		i := strings.LastIndex(line, " ")
+		value, err := strconv.ParseFloat(line[i+1:], 64)
+		if err != nil {
+			t.Fatalf("parse %q: %v", line, err)
+		}
+		series[line[:i]] = value
+	}
+	return series
+}
+
+func TestObserve(t *testing.T) {
+	m := New()
+	now := time.Now()
+	m.Observe(internal.Status{Service: "web", Timestamp: now, Status: internal.StateUp, Latency: 250})
+	m.Observe(internal.Status{Service: "db", Timestamp: now, Status: internal.StateDown, ErrorClass: "timeout", Latency: 1000})
+	m.Observe(internal.Status{Service: "db", Timestamp: now, Status: internal.StateDown, Derived: internal.StateFlapping})
+
+	series := scrape(t, m)
+	for name, want := range map[string]float64{
+		`tinyping_service_up{service="web"}`:                                  1,
+		`tinyping_service_up{service="db"}`:                                   0,
+		`tinyping_checks_total{service="db"}`:                                 2,
+		`tinyping_check_latency_seconds{service="web"}`:                       0.25,
+		`tinyping_check_failures_total{error_class="timeout",service="db"}`:   1,
+		`tinyping_check_failures_total{error_class="assertion",service="db"}`: 1,
+		`tinyping_service_state{service="db",state="FLAPPING"}`:               1,
+		`tinyping_service_state{service="db",state="DOWN"}`:                   0,
+		`tinyping_last_check_timestamp_seconds{service="web"}`:                float64(now.Unix()),
+	} {
+		if got, ok := series[name]; !ok || got != want {
+			t.Errorf("%s = %v (present %v), want %v", name, got, ok, want)
+		}
+	}
+}
+
+func TestSecondsSinceLastSuccess(t *testing.T) {
+	m := New()
+	m.started = time.Now().Add(-time.Hour)
+	m.Observe(internal.Status{Service: "web", Timestamp: time.Now().Add(-time.Minute), Status: internal.StateUp})
+	m.Observe(internal.Status{Service: "web", Timestamp: time.Now(), Status: internal.StateDown})
+	m.Observe(internal.Status{Service: "db", Timestamp: time.Now(), Status: internal.StateDown})
+
+	series := scrape(t, m)
+	// A service that was UP counts from its last success, one that never was
+	// from the start of TinyPing.
+	for service, want := range map[string]time.Duration{"web": time.Minute, "db": time.Hour} {
+		got, ok := series[`tinyping_seconds_since_last_success{service="`+service+`"}`]
+		if !ok {
+			t.Errorf("no seconds_since_last_success for %s", service)
+			continue
+		}
+		if got < want.Seconds() || got > want.Seconds()+10 {
+			t.Errorf("seconds_since_last_success for %s = %v, want about %v", service, got, want.Seconds())
+		}
+	}
+}
+
+func TestSLOMetrics(t *testing.T) {
+	m := New()
+	m.SetSLOReports(func() []internal.SLOReport {
+		return []internal.SLOReport{{
+			Service:         "web",
+			BudgetRemaining: -0.5,
+			BurnRates:       []internal.BurnRate{{Window: "1h", Rate: 14.4}},
+			Burning:         true,
+		}}
+	})
+
+	series := scrape(t, m)
+	for name, want := range map[string]float64{
+		`tinyping_slo_error_budget_remaining_ratio{service="web"}`: -0.5,
+		`tinyping_slo_burn_rate{service="web",window="1h"}`:        14.4,
+		`tinyping_slo_burning{service="web"}`:                      1,
+	} {
+		if got, ok := series[name]; !ok || got != want {
+			t.Errorf("%s = %v (present %v), want %v", name, got, ok, want)
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"int-status/internal"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// assertionFailure describes which assertion failed and why.
+type assertionFailure struct {
+	assertion string
+	reason    string
+}
+
+// codeRange is an inclusive range of HTTP status codes.
+type codeRange struct {
+	min, max int
+}
+
+// assertions is the compiled form of internal.AssertionConf.
+type assertions struct {
+	statusCodes     []codeRange
+	bodyContains    []string
+	bodyNotContains []string
+	bodyRegex       *regexp.Regexp
+	jsonPath        []internal.JSONPathAssertion
+}
+
+// compileAssertions parses status code ranges and regular expressions up front
+// so that every check does not need to.
+func compileAssertions(conf internal.AssertionConf) (*assertions, error) {
+	compiled := &assertions{
+		bodyContains:    conf.BodyContains,
+		bodyNotContains: conf.BodyNotContains,
+		jsonPath:        conf.JSONPath,
+	}
+
+	for _, code := range conf.StatusCodes {
+		r, err := parseCodeRange(code)
+		if err != nil {
+			return nil, err
+		}
+		compiled.statusCodes = append(compiled.statusCodes, r)
+	}
+
+	if conf.BodyRegex != "" {
+		re, err := regexp.Compile(conf.BodyRegex)
+		if err != nil {
+			return nil, fmt.Errorf("invalid body_regex: %w", err)
+		}
+		compiled.bodyRegex = re
+	}
+
+	for _, a := range conf.JSONPath {
+		if _, err := parseJSONPath(a.Path); err != nil {
+			return nil, err
+		}
+	}
+
+	return compiled, nil
+}
+
+// needsBody reports whether any assertion inspects the response body.
+func (a *assertions) needsBody() bool {
+	return len(a.bodyContains) > 0 || len(a.bodyNotContains) > 0 || a.bodyRegex != nil || len(a.jsonPath) > 0
+}
+
+// evaluate checks the response against every assertion and returns the first failure.
+func (a *assertions) evaluate(statusCode int, body []byte) *assertionFailure {
+	if failure := a.evaluateStatusCode(statusCode); failure != nil {
+		return failure
+	}
+
+	text := string(body)
+	for _, keyword := range a.bodyContains {
+		if !strings.Contains(text, keyword) {
+			return &assertionFailure{"body_contains", fmt.Sprintf("body does not contain %q", keyword)}
+		}
+	}
+	for _, keyword := range a.bodyNotContains {
+		if strings.Contains(text, keyword) {
+			return &assertionFailure{"body_not_contains", fmt.Sprintf("body contains %q", keyword)}
+		}
+	}
+	if a.bodyRegex != nil && !a.bodyRegex.MatchString(text) {
+		return &assertionFailure{"body_regex", fmt.Sprintf("body does not match %q", a.bodyRegex.String())}
+	}
+
+	if len(a.jsonPath) > 0 {
+		var doc interface{}
+		if err := json.Unmarshal(body, &doc); err != nil {
+			return &assertionFailure{"json_path", fmt.Sprintf("body is not valid JSON: %v", err)}
+		}
+		for _, jp := range a.jsonPath {
+			value, err := lookupJSONPath(doc, jp.Path)
+			if err != nil {
+				return &assertionFailure{"json_path", fmt.Sprintf("%s: %v", jp.Path, err)}
+			}
+			if !jsonValueEquals(value, jp.Equals) {
+				return &assertionFailure{"json_path", fmt.Sprintf("%s = %v, want %v", jp.Path, value, jp.Equals)}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (a *assertions) evaluateStatusCode(statusCode int) *assertionFailure {
+	if len(a.statusCodes) == 0 {
+		if statusCode >= 500 {
+			return &assertionFailure{"status_code", fmt.Sprintf("got status %d", statusCode)}
+		}
+		return nil
+	}
+
+	for _, r := range a.statusCodes {
+		if statusCode >= r.min && statusCode <= r.max {
+			return nil
+		}
+	}
+	return &assertionFailure{"status_code", fmt.Sprintf("got status %d, want one of %v", statusCode, a.describeStatusCodes())}
+}
+
+func (a *assertions) describeStatusCodes() []string {
+	codes := make([]string, len(a.statusCodes))
+	for i, r := range a.statusCodes {
+		if r.min == r.max {
+			codes[i] = strconv.Itoa(r.min)
+		} else {
+			codes[i] = fmt.Sprintf("%d-%d", r.min, r.max)
+		}
+	}
+	return codes
+}
+
+// parseCodeRange accepts "200", "200-299" or "2xx".
+func parseCodeRange(code string) (codeRange, error) {
+	code = strings.TrimSpace(code)
+
+	if len(code) == 3 && strings.HasSuffix(strings.ToLower(code), "xx") {
+		class, err := strconv.Atoi(code[:1])
+		if err != nil {
+			return codeRange{}, fmt.Errorf("invalid status code %q", code)
+		}
+		return codeRange{class * 100, class*100 + 99}, nil
+	}
+
+	if from, to, ok := strings.Cut(code, "-"); ok {
+		min, err1 := strconv.Atoi(strings.TrimSpace(from))
+		max, err2 := strconv.Atoi(strings.TrimSpace(to))
+		if err1 != nil || err2 != nil || min > max {
+			return codeRange{}, fmt.Errorf("invalid status code range %q", code)
+		}
+		return codeRange{min, max}, nil
+	}
+
+	value, err := strconv.Atoi(code)
+	if err != nil {
+		return codeRange{}, fmt.Errorf("invalid status code %q", code)
+	}
+	return codeRange{value, value}, nil
+}
+
+// jsonValueEquals compares a decoded JSON value with a value read from YAML.
+// Numbers decode as float64 from JSON but as int from YAML, so they are
+// compared numerically; other values are compared by their textual form.
+func jsonValueEquals(actual, expected interface{}) bool {
+	if actual == nil || expected == nil {
+		return actual == nil && expected == nil
+	}
+	if a, ok := toFloat(actual); ok {
+		if e, ok := toFloat(expected); ok {
+			return a == e
+		}
+	}
+	return fmt.Sprint(actual) == fmt.Sprint(expected)
+}
+
+// toFloat converts the numeric types produced by encoding/json and yaml.v3.
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
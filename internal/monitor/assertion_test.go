@@ -0,0 +1,145 @@
+package monitor
+
+import (
+	"gopkg.in/yaml.v3"
+	"int-status/internal"
+	"testing"
+)
+
+func TestParseCodeRange(t *testing.T) {
+	tests := []struct {
+		code    string
+		want    codeRange
+		wantErr bool
+	}{
+		{code: "200", want: codeRange{200, 200}},
+		{code: " 204 ", want: codeRange{204, 204}},
+		{code: "200-299", want: codeRange{200, 299}},
+		{code: "2xx", want: codeRange{200, 299}},
+		{code: "4XX", want: codeRange{400, 499}},
+		{code: "299-200", wantErr: true},
+		{code: "abc", wantErr: true},
+		{code: "axx", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseCodeRange(tt.code)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseCodeRange(%q) error = %v, wantErr %v", tt.code, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("parseCodeRange(%q) = %v, want %v", tt.code, got, tt.want)
+		}
+	}
+}
+
+func TestCompileAssertionsRejectsInvalidConfig(t *testing.T) {
+	tests := map[string]internal.AssertionConf{
+		"status code": {StatusCodes: []string{"2x"}},
+		"body regex":  {BodyRegex: "("},
+		"json path":   {JSONPath: []internal.JSONPathAssertion{{Path: "status"}}},
+	}
+	for name, conf := range tests {
+		if _, err := compileAssertions(conf); err == nil {
+			t.Errorf("%s: compileAssertions succeeded, want an error", name)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	body := `{"status": {"indicator": "none", "count": 1000000}, "items": [{"name": "db"}]}`
+	tests := []struct {
+		name          string
+		conf          internal.AssertionConf
+		statusCode    int
+		wantAssertion string
+	}{
+		{name: "default accepts 404", statusCode: 404},
+		{name: "default rejects 503", statusCode: 503, wantAssertion: "status_code"},
+		{name: "status code range", conf: internal.AssertionConf{StatusCodes: []string{"200-204"}}, statusCode: 204},
+		{name: "status code class", conf: internal.AssertionConf{StatusCodes: []string{"3xx", "200"}}, statusCode: 404, wantAssertion: "status_code"},
+		{name: "body contains", conf: internal.AssertionConf{BodyContains: []string{"indicator"}}, statusCode: 200},
+		{name: "body contains missing", conf: internal.AssertionConf{BodyContains: []string{"major"}}, statusCode: 200, wantAssertion: "body_contains"},
+		{name: "body not contains", conf: internal.AssertionConf{BodyNotContains: []string{"none"}}, statusCode: 200, wantAssertion: "body_not_contains"},
+		{name: "body regex", conf: internal.AssertionConf{BodyRegex: `"count":\s*\d+`}, statusCode: 200},
+		{name: "body regex mismatch", conf: internal.AssertionConf{BodyRegex: `^<html>`}, statusCode: 200, wantAssertion: "body_regex"},
+		{name: "status code before body", conf: internal.AssertionConf{StatusCodes: []string{"200"}, BodyContains: []string{"major"}}, statusCode: 500, wantAssertion: "status_code"},
+		{
+			name:       "json path",
+			conf:       internal.AssertionConf{JSONPath: []internal.JSONPathAssertion{{Path: "$.items[0].name", Equals: "db"}}},
+			statusCode: 200,
+		},
+		{
+			name:          "json path mismatch",
+			conf:          internal.AssertionConf{JSONPath: []internal.JSONPathAssertion{{Path: "$.status.indicator", Equals: "major"}}},
+			statusCode:    200,
+			wantAssertion: "json_path",
+		},
+		{
+			name:          "json path missing key",
+			conf:          internal.AssertionConf{JSONPath: []internal.JSONPathAssertion{{Path: "$.status.missing", Equals: "x"}}},
+			statusCode:    200,
+			wantAssertion: "json_path",
+		},
+	}
+	for _, tt := range tests {
+		compiled, err := compileAssertions(tt.conf)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		failure := compiled.evaluate(tt.statusCode, []byte(body))
+		got := ""
+		if failure != nil {
+			got = failure.assertion
+		}
+		if got != tt.wantAssertion {
+			t.Errorf("%s: failed assertion = %q, want %q", tt.name, got, tt.wantAssertion)
+		}
+	}
+}
+
+func TestEvaluateInvalidJSON(t *testing.T) {
+	compiled, err := compileAssertions(internal.AssertionConf{
+		JSONPath: []internal.JSONPathAssertion{{Path: "$.status", Equals: "ok"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	failure := compiled.evaluate(200, []byte("<html></html>"))
+	if failure == nil || failure.assertion != "json_path" {
+		t.Fatalf("evaluate = %+v, want a json_path failure", failure)
+	}
+}
+
+func TestJSONValueEqualsYAML(t *testing.T) {
+	// The expected values are decoded from YAML, as they are from config.yaml.
+	tests := []struct {
+		actual   interface{}
+		expected string
+		want     bool
+	}{
+		{actual: float64(1000000), expected: "1000000", want: true},
+		{actual: float64(42), expected: "42", want: true},
+		{actual: float64(42), expected: "42.0", want: true},
+		{actual: float64(0.5), expected: "0.5", want: true},
+		{actual: float64(1e20), expected: "100000000000000000000", want: true},
+		{actual: float64(42), expected: "43", want: false},
+		// A string and a number with the same text are equal, as before.
+		{actual: float64(42), expected: `"42"`, want: true},
+		{actual: "42", expected: "42", want: true},
+		{actual: "42", expected: `"42"`, want: true},
+		{actual: true, expected: "true", want: true},
+		{actual: "none", expected: "none", want: true},
+		{actual: nil, expected: "null", want: true},
+		{actual: nil, expected: "0", want: false},
+	}
+	for _, tt := range tests {
+		var expected interface{}
+		if err := yaml.Unmarshal([]byte(tt.expected), &expected); err != nil {
+			t.Fatal(err)
+		}
+		if got := jsonValueEquals(tt.actual, expected); got != tt.want {
+			t.Errorf("jsonValueEquals(%#v, %s) = %v, want %v", tt.actual, tt.expected, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"int-status/internal"
+	"net"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DNSMonitor implements ServiceStatusChecker by resolving a name against a
+// chosen resolver, bypassing the host's resolver cache.
+type DNSMonitor struct {
+	service  internal.ServiceConf
+	resolver *net.Resolver
+}
+
+// dnsRecordTypes are the supported values of DNSConf.Record; empty means A.
+var dnsRecordTypes = []string{"", "A", "AAAA", "CNAME", "MX", "TXT"}
+
+// validateDNSRecord rejects record types that lookup cannot resolve, so that
+// they fail at startup rather than on every check.
+func validateDNSRecord(record string) error {
+	if !slices.Contains(dnsRecordTypes, strings.ToUpper(record)) {
+		return fmt.Errorf("unsupported dns.record %q, want A, AAAA, CNAME, MX or TXT", record)
+	}
+	return nil
+}
+
+// NewDNSChecker creates a new DNSMonitor instance.
+func NewDNSChecker(service internal.ServiceConf) *DNSMonitor {
+	resolver := net.DefaultResolver
+	if address := service.DNS.Resolver; address != "" {
+		if _, _, err := net.SplitHostPort(address); err != nil {
+			address = net.JoinHostPort(address, "53")
+		}
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var dialer net.Dialer
+				return dialer.DialContext(ctx, network, address)
+			},
+		}
+	}
+	return &DNSMonitor{service: service, resolver: resolver}
+}
+
+// GetTargetServiceConf returns the service configuration for the monitor.
+func (d *DNSMonitor) GetTargetServiceConf() internal.ServiceConf {
+	return d.service
+}
+
+// CheckStatus resolves the configured record and checks the expected answers.
+func (d *DNSMonitor) CheckStatus(timeout time.Duration) internal.Status {
+	result := internal.Status{
+		Service: d.service.Name,
+		Status:  internal.StateDown,
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	start := time.Now()
+	answers, err := d.lookup(ctx)
+	result.Latency = time.Since(start).Milliseconds()
+	result.Timestamp = time.Now()
+
+	if err != nil {
+		setRequestError(&result, "resolve", err)
+		return result
+	}
+
+	if failure := expectAnswers(d.service.DNS.Expect, answers); failure != nil {
+		result.Assertion = failure.assertion
+		result.Message = failure.reason
+		return result
+	}
+
+	result.Status = internal.StateUp
+	return degradeIfSlow(d.service, result)
+}
+
+// lookup returns the answers for the configured record type, normalized so
+// that they can be compared with the expected values.
+func (d *DNSMonitor) lookup(ctx context.Context) ([]string, error) {
+	name := d.service.DNS.Name
+
+	switch strings.ToUpper(d.service.DNS.Record) {
+	case "", "A":
+		return d.lookupIP(ctx, "ip4", name)
+	case "AAAA":
+		return d.lookupIP(ctx, "ip6", name)
+	case "CNAME":
+		cname, err := d.resolver.LookupCNAME(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		return []string{normalizeName(cname)}, nil
+	case "MX":
+		records, err := d.resolver.LookupMX(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		answers := make([]string, len(records))
+		for i, mx := range records {
+			answers[i] = normalizeName(mx.Host)
+		}
+		return answers, nil
+	case "TXT":
+		return d.resolver.LookupTXT(ctx, name)
+	default:
+		return nil, fmt.Errorf("unsupported record type %q", d.service.DNS.Record)
+	}
+}
+
+func (d *DNSMonitor) lookupIP(ctx context.Context, network, name string) ([]string, error) {
+	ips, err := d.resolver.LookupIP(ctx, network, name)
+	if err != nil {
+		return nil, err
+	}
+	answers := make([]string, len(ips))
+	for i, ip := range ips {
+		answers[i] = ip.String()
+	}
+	return answers, nil
+}
+
+// expectAnswers requires every expected value to be among the answers.
+func expectAnswers(expected []string, answers []string) *assertionFailure {
+	for _, want := range expected {
+		found := false
+		for _, answer := range answers {
+			if answerMatches(want, answer) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return &assertionFailure{"expect", fmt.Sprintf("answer %q not found in %v", want, answers)}
+		}
+	}
+	return nil
+}
+
+func answerMatches(want, answer string) bool {
+	if ip := net.ParseIP(want); ip != nil {
+		return ip.Equal(net.ParseIP(answer))
+	}
+	return normalizeName(want) == normalizeName(answer) || want == answer
+}
+
+// normalizeName lowercases a host name and strips its trailing dot.
+func normalizeName(name string) string {
+	return strings.TrimSuffix(strings.ToLower(name), ".")
+}
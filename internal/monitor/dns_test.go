@@ -0,0 +1,283 @@
+package monitor
+
+import (
+	"encoding/binary"
+	"int-status/internal"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// DNS record types and response codes used by the test server.
+const (
+	dnsTypeA     = 1
+	dnsTypeCNAME = 5
+	dnsTypeMX    = 15
+	dnsTypeTXT   = 16
+	dnsTypeAAAA  = 28
+
+	dnsRcodeNXDomain = 3
+)
+
+// testRecord is a resource record served by testDNSServer.
+type testRecord struct {
+	rrType uint16
+	rdata  []byte
+}
+
+// testDNSServer is a minimal authoritative DNS server over UDP. Names are
+// lowercase and fully qualified. A name with a CNAME record answers every
+// query with the CNAME and the records of its target; names in silent are
+// never answered; any other unknown name is NXDOMAIN.
+type testDNSServer struct {
+	conn    net.PacketConn
+	records map[string][]testRecord
+	silent  map[string]bool
+}
+
+func startDNSServer(t *testing.T, records map[string][]testRecord, silent ...string) string {
+	t.Helper()
+	conn, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := &testDNSServer{conn: conn, records: records, silent: make(map[string]bool)}
+	for _, name := range silent {
+		server.silent[name] = true
+	}
+	go server.serve()
+	t.Cleanup(func() { conn.Close() })
+	return conn.LocalAddr().String()
+}
+
+func (s *testDNSServer) serve() {
+	buf := make([]byte, 1500)
+	for {
+		n, addr, err := s.conn.ReadFrom(buf)
+		if err != nil {
+			return
+		}
+		if response := s.answer(buf[:n]); response != nil {
+			s.conn.WriteTo(response, addr)
+		}
+	}
+}
+
+// answer builds the response to a query, or returns nil to drop it.
+func (s *testDNSServer) answer(query []byte) []byte {
+	if len(query) < 12 {
+		return nil
+	}
+	name, end, ok := readName(query, 12)
+	if !ok || len(query) < end+4 {
+		return nil
+	}
+	qtype := binary.BigEndian.Uint16(query[end:])
+	question := query[12 : end+4]
+	if s.silent[name] {
+		return nil
+	}
+
+	var answers [][]byte
+	rcode := 0
+	owner := name
+	records, known := s.records[owner]
+	for _, record := range records {
+		if record.rrType == dnsTypeCNAME {
+			answers = append(answers, encodeRR(owner, record))
+			owner, _, _ = readName(record.rdata, 0)
+			records = s.records[owner]
+			break
+		}
+	}
+	for _, record := range records {
+		if record.rrType == qtype {
+			answers = append(answers, encodeRR(owner, record))
+		}
+	}
+	if !known {
+		rcode = dnsRcodeNXDomain
+	}
+
+	header := make([]byte, 12)
+	copy(header, query[:2])
+	// QR, AA and RD (copied from the query), RA and the response code.
+	header[2] = 0x84 | query[2]&0x01
+	header[3] = 0x80 | byte(rcode)
+	binary.BigEndian.PutUint16(header[4:], 1)
+	binary.BigEndian.PutUint16(header[6:], uint16(len(answers)))
+
+	response := append(header, question...)
+	for _, answer := range answers {
+		response = append(response, answer...)
+	}
+	return response
+}
+
+// readName reads an uncompressed name at offset and returns it lowercase and
+// fully qualified, with the offset following it.
+func readName(msg []byte, offset int) (string, int, bool) {
+	var labels []string
+	for offset < len(msg) {
+		length := int(msg[offset])
+		offset++
+		if length == 0 {
+			return strings.ToLower(strings.Join(labels, ".")) + ".", offset, true
+		}
+		if length > 63 || offset+length > len(msg) {
+			return "", 0, false
+		}
+		labels = append(labels, string(msg[offset:offset+length]))
+		offset += length
+	}
+	return "", 0, false
+}
+
+func encodeName(name string) []byte {
+	var encoded []byte
+	for _, label := range strings.Split(strings.TrimSuffix(name, "."), ".") {
+		encoded = append(encoded, byte(len(label)))
+		encoded = append(encoded, label...)
+	}
+	return append(encoded, 0)
+}
+
+func encodeRR(owner string, record testRecord) []byte {
+	rr := encodeName(owner)
+	fixed := make([]byte, 10)
+	binary.BigEndian.PutUint16(fixed[0:], record.rrType)
+	binary.BigEndian.PutUint16(fixed[2:], 1) // IN
+	binary.BigEndian.PutUint32(fixed[4:], 60)
+	binary.BigEndian.PutUint16(fixed[8:], uint16(len(record.rdata)))
+	rr = append(rr, fixed...)
+	return append(rr, record.rdata...)
+}
+
+func aRecord(ip string) testRecord {
+	return testRecord{dnsTypeA, net.ParseIP(ip).To4()}
+}
+
+func aaaaRecord(ip string) testRecord {
+	return testRecord{dnsTypeAAAA, net.ParseIP(ip).To16()}
+}
+
+func cnameRecord(target string) testRecord {
+	return testRecord{dnsTypeCNAME, encodeName(target)}
+}
+
+func mxRecord(preference uint16, host string) testRecord {
+	rdata := binary.BigEndian.AppendUint16(nil, preference)
+	return testRecord{dnsTypeMX, append(rdata, encodeName(host)...)}
+}
+
+func txtRecord(text string) testRecord {
+	return testRecord{dnsTypeTXT, append([]byte{byte(len(text))}, text...)}
+}
+
+func TestDNSCheck(t *testing.T) {
+	resolver := startDNSServer(t, map[string][]testRecord{
+		"app.example.test.":   {aRecord("192.0.2.10"), aRecord("192.0.2.11"), aaaaRecord("2001:db8::10")},
+		"www.example.test.":   {cnameRecord("app.example.test.")},
+		"example.test.":       {mxRecord(10, "mx1.example.test."), mxRecord(20, "mx2.example.test."), txtRecord("v=spf1 -all")},
+		"mx1.example.test.":   {aRecord("192.0.2.25")},
+		"empty.example.test.": {txtRecord("no addresses")},
+	})
+
+	tests := []struct {
+		name          string
+		dns           internal.DNSConf
+		wantStatus    internal.State
+		wantAssertion string
+	}{
+		{name: "A without expectations", dns: internal.DNSConf{Name: "app.example.test"}, wantStatus: internal.StateUp},
+		{name: "A", dns: internal.DNSConf{Name: "app.example.test", Record: "A", Expect: []string{"192.0.2.11", "192.0.2.10"}}, wantStatus: internal.StateUp},
+		{name: "A missing answer", dns: internal.DNSConf{Name: "app.example.test", Expect: []string{"192.0.2.99"}}, wantStatus: internal.StateDown, wantAssertion: "expect"},
+		{name: "AAAA", dns: internal.DNSConf{Name: "app.example.test", Record: "aaaa", Expect: []string{"2001:db8:0::10"}}, wantStatus: internal.StateUp},
+		{name: "AAAA does not match A", dns: internal.DNSConf{Name: "app.example.test", Record: "AAAA", Expect: []string{"192.0.2.10"}}, wantStatus: internal.StateDown, wantAssertion: "expect"},
+		{name: "CNAME", dns: internal.DNSConf{Name: "www.example.test", Record: "CNAME", Expect: []string{"App.Example.Test."}}, wantStatus: internal.StateUp},
+		{name: "CNAME mismatch", dns: internal.DNSConf{Name: "www.example.test", Record: "CNAME", Expect: []string{"old.example.test"}}, wantStatus: internal.StateDown, wantAssertion: "expect"},
+		{name: "MX", dns: internal.DNSConf{Name: "example.test", Record: "MX", Expect: []string{"mx2.example.test", "mx1.example.test."}}, wantStatus: internal.StateUp},
+		{name: "MX mismatch", dns: internal.DNSConf{Name: "example.test", Record: "MX", Expect: []string{"mx3.example.test"}}, wantStatus: internal.StateDown, wantAssertion: "expect"},
+		{name: "TXT", dns: internal.DNSConf{Name: "example.test", Record: "TXT", Expect: []string{"v=spf1 -all"}}, wantStatus: internal.StateUp},
+		{name: "no A records", dns: internal.DNSConf{Name: "empty.example.test"}, wantStatus: internal.StateDown, wantAssertion: "resolve"},
+	}
+	for _, tt := range tests {
+		tt.dns.Resolver = resolver
+		checker, err := NewChecker(internal.ServiceConf{Name: "dns", Type: CheckTypeDNS, DNS: tt.dns})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		status := checker.CheckStatus(2 * time.Second)
+		if status.Status != tt.wantStatus || status.Assertion != tt.wantAssertion {
+			t.Errorf("%s: got %s (%s: %s), want %s (%s)",
+				tt.name, status.Status, status.Assertion, status.Message, tt.wantStatus, tt.wantAssertion)
+		}
+		if status.Timestamp.IsZero() {
+			t.Errorf("%s: timestamp not set", tt.name)
+		}
+	}
+}
+
+func TestDNSCheckNXDomain(t *testing.T) {
+	resolver := startDNSServer(t, map[string][]testRecord{})
+	checker, err := NewChecker(internal.ServiceConf{
+		Name: "dns",
+		Type: CheckTypeDNS,
+		DNS:  internal.DNSConf{Name: "missing.example.test", Resolver: resolver},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// A name that does not exist is an outage, not a resolver problem.
+	status := checker.CheckStatus(2 * time.Second)
+	if status.Status != internal.StateDown || status.ErrorClass != "dns" || status.Assertion != "resolve" {
+		t.Fatalf("got %s (class %q, assertion %q: %s), want DOWN (class dns, assertion resolve)",
+			status.Status, status.ErrorClass, status.Assertion, status.Message)
+	}
+}
+
+func TestDNSCheckTimeout(t *testing.T) {
+	resolver := startDNSServer(t, map[string][]testRecord{}, "slow.example.test.")
+	checker, err := NewChecker(internal.ServiceConf{
+		Name: "dns",
+		Type: CheckTypeDNS,
+		DNS:  internal.DNSConf{Name: "slow.example.test", Resolver: resolver},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// An unanswered query says nothing about the name, so it is UNKNOWN.
+	start := time.Now()
+	status := checker.CheckStatus(300 * time.Millisecond)
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("check took %s, want it bounded by the timeout", elapsed)
+	}
+	if status.Status != internal.StateUnknown || status.ErrorClass != "dns" {
+		t.Fatalf("got %s (class %q: %s), want UNKNOWN (class dns)", status.Status, status.ErrorClass, status.Message)
+	}
+}
+
+func TestNewCheckerRejectsUnsupportedDNSRecord(t *testing.T) {
+	for _, record := range []string{"SRV", "NS", "ANY"} {
+		_, err := NewChecker(internal.ServiceConf{
+			Name: "dns",
+			Type: CheckTypeDNS,
+			DNS:  internal.DNSConf{Name: "example.test", Record: record},
+		})
+		if err == nil {
+			t.Errorf("NewChecker accepted dns.record %q", record)
+		}
+	}
+	for _, record := range []string{"", "a", "AAAA", "cname", "MX", "txt"} {
+		if _, err := NewChecker(internal.ServiceConf{
+			Name: "dns",
+			Type: CheckTypeDNS,
+			DNS:  internal.DNSConf{Name: "example.test", Record: record},
+		}); err != nil {
+			t.Errorf("NewChecker rejected dns.record %q: %v", record, err)
+		}
+	}
+}
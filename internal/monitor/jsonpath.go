@@ -0,0 +1,96 @@
+package monitor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// jsonPathStep is a single object key or array index in a JSONPath expression.
+type jsonPathStep struct {
+	key     string
+	index   int
+	isIndex bool
+}
+
+// parseJSONPath parses the subset of JSONPath used by assertions:
+// "$.a.b", "$.items[0].name" and "$['key with spaces']".
+func parseJSONPath(path string) ([]jsonPathStep, error) {
+	if !strings.HasPrefix(path, "$") {
+		return nil, fmt.Errorf("invalid json path %q: must start with $", path)
+	}
+
+	var steps []jsonPathStep
+	rest := path[1:]
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			rest = rest[1:]
+			end := strings.IndexAny(rest, ".[")
+			if end == -1 {
+				end = len(rest)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("invalid json path %q: empty key", path)
+			}
+			steps = append(steps, jsonPathStep{key: rest[:end]})
+			rest = rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("invalid json path %q: unclosed bracket", path)
+			}
+			inner := rest[1:end]
+			rest = rest[end+1:]
+			if len(inner) >= 2 && (inner[0] == '\'' || inner[0] == '"') && inner[len(inner)-1] == inner[0] {
+				steps = append(steps, jsonPathStep{key: inner[1 : len(inner)-1]})
+				continue
+			}
+			index, err := strconv.Atoi(inner)
+			if err != nil {
+				return nil, fmt.Errorf("invalid json path %q: bad index %q", path, inner)
+			}
+			steps = append(steps, jsonPathStep{index: index, isIndex: true})
+		default:
+			return nil, fmt.Errorf("invalid json path %q: unexpected %q", path, rest[0])
+		}
+	}
+	return steps, nil
+}
+
+// lookupJSONPath returns the value at path inside a document decoded by encoding/json.
+func lookupJSONPath(doc interface{}, path string) (interface{}, error) {
+	steps, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+
+	current := doc
+	for _, step := range steps {
+		if step.isIndex {
+			list, ok := current.([]interface{})
+			if !ok {
+				return nil, fmt.Errorf("not an array at [%d]", step.index)
+			}
+			if step.index < 0 {
+				step.index += len(list)
+			}
+			if step.index < 0 || step.index >= len(list) {
+				return nil, fmt.Errorf("index [%d] out of range", step.index)
+			}
+			current = list[step.index]
+			continue
+		}
+
+		object, ok := current.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("not an object at %q", step.key)
+		}
+		value, ok := object[step.key]
+		if !ok {
+			return nil, fmt.Errorf("key %q not found", step.key)
+		}
+		current = value
+	}
+	return current, nil
+}
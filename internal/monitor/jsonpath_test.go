@@ -0,0 +1,72 @@
+package monitor
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+)
+
+func TestParseJSONPath(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    []jsonPathStep
+		wantErr bool
+	}{
+		{path: "$", want: nil},
+		{path: "$.a.b", want: []jsonPathStep{{key: "a"}, {key: "b"}}},
+		{path: "$.items[0].name", want: []jsonPathStep{{key: "items"}, {index: 0, isIndex: true}, {key: "name"}}},
+		{path: "$['key with spaces']", want: []jsonPathStep{{key: "key with spaces"}}},
+		{path: `$["a.b"][-1]`, want: []jsonPathStep{{key: "a.b"}, {index: -1, isIndex: true}}},
+		{path: "a.b", wantErr: true},
+		{path: "$.", wantErr: true},
+		{path: "$.a..b", wantErr: true},
+		{path: "$[0", wantErr: true},
+		{path: "$[x]", wantErr: true},
+		{path: "$a", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := parseJSONPath(tt.path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parseJSONPath(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("parseJSONPath(%q) = %+v, want %+v", tt.path, got, tt.want)
+		}
+	}
+}
+
+func TestLookupJSONPath(t *testing.T) {
+	var doc interface{}
+	body := `{"status": {"indicator": "none"}, "items": [{"name": "db"}, {"name": "cache"}], "odd key": 1, "n": null}`
+	if err := json.Unmarshal([]byte(body), &doc); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path    string
+		want    interface{}
+		wantErr bool
+	}{
+		{path: "$.status.indicator", want: "none"},
+		{path: "$.items[1].name", want: "cache"},
+		{path: "$.items[-1].name", want: "cache"},
+		{path: "$['odd key']", want: float64(1)},
+		{path: "$.n", want: nil},
+		{path: "$.items[2]", wantErr: true},
+		{path: "$.items[-3]", wantErr: true},
+		{path: "$.status[0]", wantErr: true},
+		{path: "$.items.name", wantErr: true},
+		{path: "$.missing", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := lookupJSONPath(doc, tt.path)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("lookupJSONPath(%q) error = %v, wantErr %v", tt.path, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("lookupJSONPath(%q) = %#v, want %#v", tt.path, got, tt.want)
+		}
+	}
+}
@@ -1,19 +1,33 @@
 package monitor
 
 import (
+	"crypto/tls"
+	"fmt"
 	"int-status/internal"
+	"io"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 )
 
+// maxBodySize caps how much of a response body is read for assertions.
+const maxBodySize = 1 << 20
+
 // StatusMonitor implements ServiceStatusChecker for HTTP-based services.
 type StatusMonitor struct {
-	service internal.ServiceConf
+	service    internal.ServiceConf
+	assertions *assertions
 }
 
-// NewServiceChecker creates a new StatusMonitor instance.
-func NewServiceChecker(service internal.ServiceConf) *StatusMonitor {
-	return &StatusMonitor{service: service}
+// NewServiceChecker creates a new StatusMonitor instance. It fails when the
+// assertion config is invalid, so that the mistake stops TinyPing at startup.
+func NewServiceChecker(service internal.ServiceConf) (*StatusMonitor, error) {
+	compiled, err := compileAssertions(service.Assertions)
+	if err != nil {
+		return nil, fmt.Errorf("invalid assertions: %w", err)
+	}
+	return &StatusMonitor{service: service, assertions: compiled}, nil
 }
 
 // GetTargetServiceConf returns the service configuration for the monitor.
@@ -26,20 +40,129 @@ func (h *StatusMonitor) CheckStatus(timeout time.Duration) internal.Status {
 	client := http.Client{
 		Timeout: timeout,
 	}
+	if h.service.TLS.Check {
+		// Verification is done by evaluateCertificate, as for "tls" checks, so
+		// that an expired or untrusted certificate is reported with its details
+		// instead of a bare handshake error. Every check makes a new handshake.
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = &tls.Config{
+			ServerName:         h.service.TLS.ServerName,
+			InsecureSkipVerify: true,
+		}
+		transport.DisableKeepAlives = true
+		client.Transport = transport
+	}
+
+	result := internal.Status{
+		Service: h.service.Name,
+		Status:  internal.StateDown,
+	}
 
 	start := time.Now()
-	resp, err := client.Get(h.service.API.URL)
-	latency := time.Since(start).Milliseconds()
+	statusCode, body, tlsState, err := h.do(&client)
+	result.Latency = time.Since(start).Milliseconds()
+	result.Timestamp = time.Now()
+
+	if err != nil {
+		setRequestError(&result, "request", err)
+		return result
+	}
 
-	status := "UP"
-	if err != nil || resp.StatusCode >= 500 {
-		status = "DOWN"
+	result.Status = internal.StateUp
+	if h.service.TLS.Check && tlsState != nil {
+		// A certificate that clients reject fails the service whatever the
+		// response, so it is reported first.
+		serverName := h.service.TLS.ServerName
+		if serverName == "" {
+			// The TLS state has no server name for IP addresses.
+			target, _ := url.Parse(h.service.API.URL)
+			serverName = target.Hostname()
+		}
+		result = evaluateCertificate(h.service, tlsState.PeerCertificates, serverName, result)
+		if result.Status == internal.StateDown {
+			return result
+		}
 	}
 
-	return internal.Status{
-		Service:   h.service.Name,
-		Timestamp: time.Now(),
-		Status:    status,
-		Latency:   latency,
+	if failure := h.assertions.evaluate(statusCode, body); failure != nil {
+		result.Status = internal.StateDown
+		result.Assertion = failure.assertion
+		result.Message = failure.reason
+		return result
+	}
+	return degradeIfSlow(h.service, result)
+}
+
+// do sends the configured request and returns the status code, the response
+// body when an assertion needs it, and the TLS state of HTTPS connections.
+func (h *StatusMonitor) do(client *http.Client) (int, []byte, *tls.ConnectionState, error) {
+	req, err := h.newRequest()
+	if err != nil {
+		return 0, nil, nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	var body []byte
+	if h.assertions.needsBody() {
+		body, err = readBody(resp)
+		if err != nil {
+			return 0, nil, nil, err
+		}
+	} else {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, maxBodySize))
+	}
+	return resp.StatusCode, body, resp.TLS, nil
+}
+
+// readBody reads at most maxBodySize bytes of the response body.
+func readBody(resp *http.Response) ([]byte, error) {
+	return io.ReadAll(io.LimitReader(resp.Body, maxBodySize))
+}
+
+// newRequest builds the HTTP request described by the service's API config.
+func (h *StatusMonitor) newRequest() (*http.Request, error) {
+	api := h.service.API
+
+	method := strings.ToUpper(api.Method)
+	if method == "" {
+		method = http.MethodGet
+	}
+
+	target, err := url.Parse(api.URL)
+	if err != nil {
+		return nil, err
+	}
+	if len(api.Query) > 0 {
+		query := target.Query()
+		for key, value := range api.Query {
+			query.Set(key, value)
+		}
+		target.RawQuery = query.Encode()
+	}
+
+	var body io.Reader
+	if api.Body != "" {
+		body = strings.NewReader(api.Body)
+	}
+
+	req, err := http.NewRequest(method, target.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range api.Headers {
+		if strings.EqualFold(key, "Host") {
+			req.Host = value
+			continue
+		}
+		req.Header.Set(key, value)
+	}
+	if api.Body != "" && req.Header.Get("Content-Type") == "" {
+		req.Header.Set("Content-Type", "application/json")
 	}
+	return req, nil
 }
@@ -1,12 +1,52 @@
 package monitor
 
 import (
+	"fmt"
 	"int-status/internal"
 	"time"
 )
 
+// Supported values of ServiceConf.Type.
+const (
+	CheckTypeHTTP       = "http"
+	CheckTypeStatuspage = "statuspage"
+	CheckTypeTCP        = "tcp"
+	CheckTypeDNS        = "dns"
+	CheckTypeTLS        = "tls"
+)
+
 // ServiceStatusChecker defines the interface for checking service status.
 type ServiceStatusChecker interface {
 	GetTargetServiceConf() internal.ServiceConf
 	CheckStatus(timeout time.Duration) internal.Status
 }
+
+// NewChecker creates the ServiceStatusChecker matching the service's check
+// type. It fails on a config that no check could succeed with.
+func NewChecker(service internal.ServiceConf) (ServiceStatusChecker, error) {
+	switch service.Type {
+	case "", CheckTypeHTTP:
+		checker, err := NewServiceChecker(service)
+		if err != nil {
+			return nil, fmt.Errorf("service %s: %w", service.Name, err)
+		}
+		return checker, nil
+	case CheckTypeStatuspage:
+		checker, err := NewStatuspageChecker(service)
+		if err != nil {
+			return nil, fmt.Errorf("service %s: %w", service.Name, err)
+		}
+		return checker, nil
+	case CheckTypeTCP:
+		return NewTCPChecker(service), nil
+	case CheckTypeDNS:
+		if err := validateDNSRecord(service.DNS.Record); err != nil {
+			return nil, fmt.Errorf("service %s: %w", service.Name, err)
+		}
+		return NewDNSChecker(service), nil
+	case CheckTypeTLS:
+		return NewTLSChecker(service), nil
+	default:
+		return nil, fmt.Errorf("service %s: unknown check type %q", service.Name, service.Type)
+	}
+}
@@ -0,0 +1,97 @@
+package monitor
+
+import (
+	"int-status/internal"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCheckStatusSendsConfiguredRequest(t *testing.T) {
+	var got *http.Request
+	var gotBody string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		got, gotBody = r, string(body)
+		w.Write([]byte(`{"ok": true}`))
+	}))
+	defer server.Close()
+
+	checker, err := NewServiceChecker(internal.ServiceConf{
+		Name: "api",
+		API: internal.APIConf{
+			Method:  "post",
+			URL:     server.URL + "/health?a=1",
+			Headers: map[string]string{"Authorization": "Bearer token", "Host": "status.example.com"},
+			Query:   map[string]string{"b": "2"},
+			Body:    `{"ping": 1}`,
+		},
+		Assertions: internal.AssertionConf{
+			JSONPath: []internal.JSONPathAssertion{{Path: "$.ok", Equals: true}},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status := checker.CheckStatus(time.Second)
+	if status.Status != internal.StateUp {
+		t.Fatalf("status = %s (%s: %s), want UP", status.Status, status.Assertion, status.Message)
+	}
+	if got.Method != http.MethodPost {
+		t.Errorf("method = %s, want POST", got.Method)
+	}
+	if got.URL.Path != "/health" || got.URL.Query().Get("a") != "1" || got.URL.Query().Get("b") != "2" {
+		t.Errorf("url = %s, want /health with a=1 and b=2", got.URL)
+	}
+	if got.Header.Get("Authorization") != "Bearer token" {
+		t.Errorf("Authorization = %q", got.Header.Get("Authorization"))
+	}
+	if got.Host != "status.example.com" {
+		t.Errorf("Host = %q, want status.example.com", got.Host)
+	}
+	if got.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("Content-Type = %q, want application/json", got.Header.Get("Content-Type"))
+	}
+	if gotBody != `{"ping": 1}` {
+		t.Errorf("body = %q", gotBody)
+	}
+}
+
+func TestCheckStatusAssertionFailure(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("maintenance in progress"))
+	}))
+	defer server.Close()
+
+	checker, err := NewServiceChecker(internal.ServiceConf{
+		Name:       "web",
+		API:        internal.APIConf{URL: server.URL},
+		Assertions: internal.AssertionConf{BodyNotContains: []string{"maintenance"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	status := checker.CheckStatus(time.Second)
+	if status.Status != internal.StateDown || status.Assertion != "body_not_contains" {
+		t.Fatalf("status = %s (%s), want DOWN (body_not_contains)", status.Status, status.Assertion)
+	}
+}
+
+func TestNewCheckerRejectsInvalidAssertions(t *testing.T) {
+	for _, checkType := range []string{"", CheckTypeHTTP, CheckTypeStatuspage} {
+		_, err := NewChecker(internal.ServiceConf{
+			Name:       "web",
+			Type:       checkType,
+			API:        internal.APIConf{URL: "http://127.0.0.1:1"},
+			Assertions: internal.AssertionConf{StatusCodes: []string{"two hundred"}},
+		})
+		if err == nil || !strings.Contains(err.Error(), "service web") {
+			t.Errorf("type %q: err = %v, want the invalid status code reported", checkType, err)
+		}
+	}
+}
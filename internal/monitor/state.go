@@ -0,0 +1,73 @@
+package monitor
+
+import (
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"int-status/internal"
+	"io"
+	"net"
+	"syscall"
+)
+
+// setRequestError records a failure to reach the service at all, as opposed
+// to a response that failed an assertion.
+func setRequestError(result *internal.Status, assertion string, err error) {
+	result.Status = requestErrorState(err)
+	result.Assertion = assertion
+	result.Message = err.Error()
+	result.ErrorClass = classifyError(err)
+}
+
+// classifyError buckets transport errors into a small, fixed set of classes
+// suitable for metric labels.
+func classifyError(err error) string {
+	var dnsErr *net.DNSError
+	var netErr net.Error
+	var certErr *tls.CertificateVerificationError
+	var recordErr tls.RecordHeaderError
+
+	switch {
+	case errors.As(err, &dnsErr):
+		return "dns"
+	case errors.As(err, &certErr), errors.As(err, &recordErr):
+		return "tls"
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "connection_refused"
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, io.EOF), errors.Is(err, io.ErrUnexpectedEOF):
+		return "connection_reset"
+	case errors.Is(err, syscall.ENETUNREACH), errors.Is(err, syscall.EHOSTUNREACH), errors.Is(err, syscall.ENETDOWN):
+		return "network"
+	case errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	default:
+		return "other"
+	}
+}
+
+// requestErrorState tells a failing service apart from a monitoring host that
+// cannot reach the network at all. The latter is UNKNOWN, not DOWN.
+func requestErrorState(err error) internal.State {
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) && !dnsErr.IsNotFound && (dnsErr.IsTemporary || dnsErr.IsTimeout) {
+		return internal.StateUnknown
+	}
+	if errors.Is(err, syscall.ENETUNREACH) || errors.Is(err, syscall.ENETDOWN) {
+		return internal.StateUnknown
+	}
+	return internal.StateDown
+}
+
+// degradeIfSlow marks an UP result as DEGRADED when it exceeded the
+// service's degraded_latency.
+func degradeIfSlow(service internal.ServiceConf, result internal.Status) internal.Status {
+	if result.Status != internal.StateUp || service.DegradedLatency <= 0 {
+		return result
+	}
+	if result.Latency > service.DegradedLatency.Milliseconds() {
+		result.Status = internal.StateDegraded
+		result.Assertion = "latency"
+		result.Message = fmt.Sprintf("latency %d ms exceeds %s", result.Latency, service.DegradedLatency)
+	}
+	return result
+}
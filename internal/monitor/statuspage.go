@@ -0,0 +1,154 @@
+package monitor
+
+import (
+	"encoding/json"
+	"fmt"
+	"int-status/internal"
+	"net/http"
+	"time"
+)
+
+// statuspageResponse is the subset of an Atlassian Statuspage status.json or
+// summary.json document that TinyPing reads. status.json has no components.
+type statuspageResponse struct {
+	Status struct {
+		Indicator   string `json:"indicator"`
+		Description string `json:"description"`
+	} `json:"status"`
+	Components []struct {
+		Name   string `json:"name"`
+		Status string `json:"status"`
+		Group  bool   `json:"group"`
+	} `json:"components"`
+}
+
+// StatuspageMonitor implements ServiceStatusChecker for Atlassian Statuspage
+// feeds. Unlike StatusMonitor it reports what the page says, not whether the
+// page itself is reachable.
+type StatuspageMonitor struct {
+	http *StatusMonitor
+}
+
+// NewStatuspageChecker creates a new StatuspageMonitor instance.
+func NewStatuspageChecker(service internal.ServiceConf) (*StatuspageMonitor, error) {
+	checker, err := NewServiceChecker(service)
+	if err != nil {
+		return nil, err
+	}
+	return &StatuspageMonitor{http: checker}, nil
+}
+
+// GetTargetServiceConf returns the service configuration for the monitor.
+func (s *StatuspageMonitor) GetTargetServiceConf() internal.ServiceConf {
+	return s.http.service
+}
+
+// CheckStatus fetches the Statuspage document, evaluates the configured
+// assertions against it and maps its indicator and components to TinyPing
+// statuses.
+func (s *StatuspageMonitor) CheckStatus(timeout time.Duration) internal.Status {
+	client := http.Client{
+		Timeout: timeout,
+	}
+
+	result := internal.Status{
+		Service: s.http.service.Name,
+		Status:  internal.StateDown,
+	}
+
+	start := time.Now()
+	statusCode, body, err := s.fetch(&client)
+	result.Latency = time.Since(start).Milliseconds()
+	result.Timestamp = time.Now()
+
+	if err != nil {
+		setRequestError(&result, "request", err)
+		return result
+	}
+	// Without configured status codes only a successful response is a feed.
+	if len(s.http.assertions.statusCodes) == 0 && (statusCode < 200 || statusCode > 299) {
+		result.Assertion = "status_code"
+		result.Message = fmt.Sprintf("got status %d", statusCode)
+		return result
+	}
+	if failure := s.http.assertions.evaluate(statusCode, body); failure != nil {
+		result.Assertion = failure.assertion
+		result.Message = failure.reason
+		return result
+	}
+
+	var page statuspageResponse
+	if err := json.Unmarshal(body, &page); err != nil {
+		result.Assertion = "statuspage"
+		result.Message = fmt.Sprintf("invalid statuspage response: %v", err)
+		return result
+	}
+
+	result.Status = indicatorStatus(page.Status.Indicator)
+	if result.Status != internal.StateUp {
+		result.Message = page.Status.Description
+	}
+	for _, component := range page.Components {
+		if component.Group {
+			continue
+		}
+		result.Components = append(result.Components, internal.ComponentStatus{
+			Name:   component.Name,
+			Status: componentStatus(component.Status),
+		})
+	}
+	return degradeIfSlow(s.http.service, result)
+}
+
+// fetch always reads the body, regardless of configured assertions.
+func (s *StatuspageMonitor) fetch(client *http.Client) (int, []byte, error) {
+	req, err := s.http.newRequest()
+	if err != nil {
+		return 0, nil, err
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return 0, nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := readBody(resp)
+	if err != nil {
+		return 0, nil, err
+	}
+	return resp.StatusCode, body, nil
+}
+
+// indicatorStatus maps status.indicator (none, minor, major, critical, maintenance).
+func indicatorStatus(indicator string) internal.State {
+	switch indicator {
+	case "none":
+		return internal.StateUp
+	case "minor":
+		return internal.StateDegraded
+	case "maintenance":
+		return internal.StateMaintenance
+	case "major", "critical":
+		return internal.StateDown
+	default:
+		return internal.StateUnknown
+	}
+}
+
+// componentStatus maps a component status (operational, degraded_performance,
+// partial_outage, major_outage, under_maintenance).
+func componentStatus(status string) internal.State {
+	switch status {
+	case "operational":
+		return internal.StateUp
+	case "degraded_performance", "partial_outage":
+		return internal.StateDegraded
+	case "under_maintenance":
+		return internal.StateMaintenance
+	case "major_outage":
+		return internal.StateDown
+	default:
+		return internal.StateUnknown
+	}
+}
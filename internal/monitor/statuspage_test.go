@@ -0,0 +1,92 @@
+package monitor
+
+import (
+	"fmt"
+	"int-status/internal"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+const testSummary = `{
+	"status": {"indicator": "%s", "description": "%s"},
+	"components": [
+		{"name": "API", "status": "operational"},
+		{"name": "Regions", "status": "operational", "group": true},
+		{"name": "Webhooks", "status": "partial_outage"}
+	]
+}`
+
+func startStatuspage(t *testing.T, code int, body string) string {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(code)
+		w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestStatuspageCheck(t *testing.T) {
+	tests := []struct {
+		name          string
+		code          int
+		indicator     string
+		description   string
+		assertions    internal.AssertionConf
+		wantStatus    internal.State
+		wantAssertion string
+		wantMessage   string
+	}{
+		{name: "operational", code: 200, indicator: "none", description: "All Systems Operational", wantStatus: internal.StateUp},
+		{name: "minor", code: 200, indicator: "minor", description: "Partial System Outage", wantStatus: internal.StateDegraded, wantMessage: "Partial System Outage"},
+		{name: "major", code: 200, indicator: "major", description: "Major System Outage", wantStatus: internal.StateDown, wantMessage: "Major System Outage"},
+		{name: "not found", code: 404, indicator: "none", wantStatus: internal.StateDown, wantAssertion: "status_code", wantMessage: "got status 404"},
+		{
+			name:          "body assertion",
+			code:          200,
+			indicator:     "none",
+			assertions:    internal.AssertionConf{BodyContains: []string{"Payments"}},
+			wantStatus:    internal.StateDown,
+			wantAssertion: "body_contains",
+			wantMessage:   `body does not contain "Payments"`,
+		},
+		{
+			name:       "json path assertion",
+			code:       200,
+			indicator:  "none",
+			assertions: internal.AssertionConf{JSONPath: []internal.JSONPathAssertion{{Path: "$.components[0].status", Equals: "operational"}}},
+			wantStatus: internal.StateUp,
+		},
+		{
+			name:          "configured status codes",
+			code:          203,
+			indicator:     "none",
+			assertions:    internal.AssertionConf{StatusCodes: []string{"200"}},
+			wantStatus:    internal.StateDown,
+			wantAssertion: "status_code",
+			wantMessage:   "got status 203, want one of [200]",
+		},
+	}
+	for _, tt := range tests {
+		url := startStatuspage(t, tt.code, fmt.Sprintf(testSummary, tt.indicator, tt.description))
+		checker, err := NewChecker(internal.ServiceConf{
+			Name:       "vendor",
+			Type:       CheckTypeStatuspage,
+			API:        internal.APIConf{URL: url},
+			Assertions: tt.assertions,
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		status := checker.CheckStatus(time.Second)
+		if status.Status != tt.wantStatus || status.Assertion != tt.wantAssertion || status.Message != tt.wantMessage {
+			t.Errorf("%s: got %s (%q: %q), want %s (%q: %q)",
+				tt.name, status.Status, status.Assertion, status.Message, tt.wantStatus, tt.wantAssertion, tt.wantMessage)
+		}
+		if tt.wantAssertion == "" && len(status.Components) != 2 {
+			t.Errorf("%s: components = %v, want API and Webhooks", tt.name, status.Components)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package monitor
+
+import (
+	"bytes"
+	"fmt"
+	"int-status/internal"
+	"net"
+	"time"
+)
+
+// maxBannerSize caps how much is read while waiting for an expected banner.
+const maxBannerSize = 4096
+
+// TCPMonitor implements ServiceStatusChecker for plain TCP services such as
+// databases, message brokers and SSH bastions.
+type TCPMonitor struct {
+	service internal.ServiceConf
+}
+
+// NewTCPChecker creates a new TCPMonitor instance.
+func NewTCPChecker(service internal.ServiceConf) *TCPMonitor {
+	return &TCPMonitor{service: service}
+}
+
+// GetTargetServiceConf returns the service configuration for the monitor.
+func (t *TCPMonitor) GetTargetServiceConf() internal.ServiceConf {
+	return t.service
+}
+
+// CheckStatus connects to the configured address, optionally exchanges a
+// payload, and reports the connect latency.
+func (t *TCPMonitor) CheckStatus(timeout time.Duration) internal.Status {
+	result := internal.Status{
+		Service: t.service.Name,
+		Status:  internal.StateDown,
+	}
+
+	deadline := time.Now().Add(timeout)
+	start := time.Now()
+	conn, err := net.DialTimeout("tcp", t.service.TCP.Address, timeout)
+	result.Latency = time.Since(start).Milliseconds()
+	if err != nil {
+		result.Timestamp = time.Now()
+		setRequestError(&result, "connect", err)
+		return result
+	}
+	defer conn.Close()
+	conn.SetDeadline(deadline)
+
+	if failure := t.exchange(conn); failure != nil {
+		result.Timestamp = time.Now()
+		result.Assertion = failure.assertion
+		result.Message = failure.reason
+		return result
+	}
+
+	result.Timestamp = time.Now()
+	result.Status = internal.StateUp
+	return degradeIfSlow(t.service, result)
+}
+
+// exchange writes the configured payload and waits for the expected banner.
+func (t *TCPMonitor) exchange(conn net.Conn) *assertionFailure {
+	conf := t.service.TCP
+
+	if conf.Send != "" {
+		if _, err := conn.Write([]byte(conf.Send)); err != nil {
+			return &assertionFailure{"send", err.Error()}
+		}
+	}
+
+	if conf.Expect == "" {
+		return nil
+	}
+
+	expect := []byte(conf.Expect)
+	received := make([]byte, 0, 512)
+	buf := make([]byte, 512)
+	for len(received) < maxBannerSize {
+		n, err := conn.Read(buf)
+		received = append(received, buf[:n]...)
+		if bytes.Contains(received, expect) {
+			return nil
+		}
+		if err != nil {
+			return &assertionFailure{"expect", fmt.Sprintf("did not receive %q: %v", conf.Expect, err)}
+		}
+	}
+	return &assertionFailure{"expect", fmt.Sprintf("did not receive %q within %d bytes", conf.Expect, maxBannerSize)}
+}
@@ -0,0 +1,135 @@
+package monitor
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"int-status/internal"
+	"net"
+	"time"
+)
+
+// defaultExpiryWarningDays is used when tls.expiry_warning_days is not set.
+const defaultExpiryWarningDays = 14
+
+// TLSMonitor implements ServiceStatusChecker for TLS certificates. It reports
+// expiry, issuer, hostname coverage and chain validity.
+type TLSMonitor struct {
+	service internal.ServiceConf
+}
+
+// NewTLSChecker creates a new TLSMonitor instance.
+func NewTLSChecker(service internal.ServiceConf) *TLSMonitor {
+	return &TLSMonitor{service: service}
+}
+
+// GetTargetServiceConf returns the service configuration for the monitor.
+func (t *TLSMonitor) GetTargetServiceConf() internal.ServiceConf {
+	return t.service
+}
+
+// CheckStatus performs a TLS handshake and evaluates the presented certificate.
+func (t *TLSMonitor) CheckStatus(timeout time.Duration) internal.Status {
+	result := internal.Status{
+		Service: t.service.Name,
+		Status:  internal.StateDown,
+	}
+
+	address := t.service.TLS.Address
+	if _, _, err := net.SplitHostPort(address); err != nil {
+		address = net.JoinHostPort(address, "443")
+	}
+	host, _, _ := net.SplitHostPort(address)
+	serverName := t.service.TLS.ServerName
+	if serverName == "" {
+		serverName = host
+	}
+
+	dialer := &net.Dialer{Timeout: timeout}
+	start := time.Now()
+	// Verification is done by evaluateCertificate so that an invalid chain is
+	// still reported with its details instead of a bare handshake error.
+	conn, err := tls.DialWithDialer(dialer, "tcp", address, &tls.Config{
+		ServerName:         serverName,
+		InsecureSkipVerify: true,
+	})
+	result.Latency = time.Since(start).Milliseconds()
+	result.Timestamp = time.Now()
+	if err != nil {
+		setRequestError(&result, "handshake", err)
+		return result
+	}
+	defer conn.Close()
+
+	result.Status = internal.StateUp
+	result = evaluateCertificate(t.service, conn.ConnectionState().PeerCertificates, serverName, result)
+	return degradeIfSlow(t.service, result)
+}
+
+// evaluateCertificate inspects the peer certificates and downgrades the result
+// when the certificate is invalid or about to expire.
+func evaluateCertificate(service internal.ServiceConf, certs []*x509.Certificate, serverName string, result internal.Status) internal.Status {
+	if len(certs) == 0 {
+		result.Status = internal.StateDown
+		result.Assertion = "certificate"
+		result.Message = "no peer certificate presented"
+		return result
+	}
+
+	leaf := certs[0]
+	now := time.Now()
+	info := &internal.CertificateInfo{
+		Subject:  leaf.Subject.CommonName,
+		Issuer:   issuerName(leaf),
+		NotAfter: leaf.NotAfter,
+		DaysLeft: int(leaf.NotAfter.Sub(now).Hours() / 24),
+		DNSNames: leaf.DNSNames,
+	}
+	info.HostnameValid = leaf.VerifyHostname(serverName) == nil
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range certs[1:] {
+		intermediates.AddCert(cert)
+	}
+	_, chainErr := leaf.Verify(x509.VerifyOptions{
+		Intermediates: intermediates,
+		CurrentTime:   now,
+	})
+	info.ChainValid = chainErr == nil
+	result.Certificate = info
+
+	warningDays := service.TLS.ExpiryWarningDays
+	if warningDays <= 0 {
+		warningDays = defaultExpiryWarningDays
+	}
+
+	switch {
+	case now.After(leaf.NotAfter):
+		result.Status = internal.StateDown
+		result.Assertion = "certificate_expiry"
+		result.Message = fmt.Sprintf("certificate expired on %s", leaf.NotAfter.Format(time.RFC3339))
+	case !info.HostnameValid:
+		result.Status = internal.StateDown
+		result.Assertion = "certificate_hostname"
+		result.Message = fmt.Sprintf("certificate does not cover %s (SANs: %v)", serverName, leaf.DNSNames)
+	case !info.ChainValid:
+		result.Status = internal.StateDown
+		result.Assertion = "certificate_chain"
+		result.Message = fmt.Sprintf("invalid certificate chain: %v", chainErr)
+	case info.DaysLeft < warningDays && result.Status == internal.StateUp:
+		result.Status = internal.StateDegraded
+		result.Assertion = "certificate_expiry"
+		result.Message = fmt.Sprintf("certificate expires in %d days", info.DaysLeft)
+	}
+	return result
+}
+
+func issuerName(cert *x509.Certificate) string {
+	if cert.Issuer.CommonName != "" {
+		return cert.Issuer.CommonName
+	}
+	if len(cert.Issuer.Organization) > 0 {
+		return cert.Issuer.Organization[0]
+	}
+	return cert.Issuer.String()
+}
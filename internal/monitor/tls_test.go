@@ -0,0 +1,192 @@
+package monitor
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"int-status/internal"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// testCA is trusted as a system root for the tests of this package.
+var testCA *certAuthority
+
+// certAuthority issues certificates for the test servers.
+type certAuthority struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+}
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "tinyping-monitor")
+	if err != nil {
+		panic(err)
+	}
+	testCA = newCertAuthority("TinyPing Test CA")
+	roots := filepath.Join(dir, "roots.pem")
+	if err := os.WriteFile(roots, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: testCA.cert.Raw}), 0o600); err != nil {
+		panic(err)
+	}
+	// The system roots are loaded once, on first use, from SSL_CERT_FILE.
+	os.Setenv("SSL_CERT_FILE", roots)
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func newCertAuthority(name string) *certAuthority {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(365 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		panic(err)
+	}
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		panic(err)
+	}
+	return &certAuthority{cert: cert, key: key}
+}
+
+// issue creates a certificate for 127.0.0.1 and localhost valid until notAfter.
+func (ca *certAuthority) issue(t *testing.T, notAfter time.Time) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// startTLSServer serves code over TLS with cert and returns its URL.
+func startTLSServer(t *testing.T, cert tls.Certificate, code int) string {
+	t.Helper()
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(code)
+	}))
+	server.TLS = &tls.Config{Certificates: []tls.Certificate{cert}}
+	server.StartTLS()
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func TestCertificateChecks(t *testing.T) {
+	day := 24 * time.Hour
+	untrusted := newCertAuthority("Untrusted CA")
+	tests := []struct {
+		name          string
+		cert          tls.Certificate
+		code          int
+		serverName    string
+		wantStatus    internal.State
+		wantAssertion string
+		wantMessage   string
+	}{
+		{name: "valid", cert: testCA.issue(t, time.Now().Add(90*day)), code: 200, wantStatus: internal.StateUp},
+		{name: "expiring soon", cert: testCA.issue(t, time.Now().Add(5*day)), code: 200, wantStatus: internal.StateDegraded, wantAssertion: "certificate_expiry", wantMessage: "certificate expires in 4 days"},
+		{name: "expired", cert: testCA.issue(t, time.Now().Add(-day)), code: 200, wantStatus: internal.StateDown, wantAssertion: "certificate_expiry", wantMessage: "certificate expired on"},
+		{name: "wrong host", cert: testCA.issue(t, time.Now().Add(90*day)), code: 200, serverName: "status.example.com", wantStatus: internal.StateDown, wantAssertion: "certificate_hostname", wantMessage: "certificate does not cover status.example.com"},
+		{name: "untrusted", cert: untrusted.issue(t, time.Now().Add(90*day)), code: 200, wantStatus: internal.StateDown, wantAssertion: "certificate_chain", wantMessage: "invalid certificate chain"},
+	}
+	for _, tt := range tests {
+		url := startTLSServer(t, tt.cert, tt.code)
+		issuer := tt.cert.Leaf
+		if issuer == nil {
+			issuer, _ = x509.ParseCertificate(tt.cert.Certificate[0])
+		}
+		tlsConf := internal.TLSConf{Check: true, ServerName: tt.serverName, Address: strings.TrimPrefix(url, "https://")}
+		for _, checkType := range []string{CheckTypeHTTP, CheckTypeTLS} {
+			checker, err := NewChecker(internal.ServiceConf{
+				Name: "web",
+				Type: checkType,
+				API:  internal.APIConf{URL: url},
+				TLS:  tlsConf,
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			status := checker.CheckStatus(2 * time.Second)
+			if status.Status != tt.wantStatus || status.Assertion != tt.wantAssertion || !strings.HasPrefix(status.Message, tt.wantMessage) {
+				t.Errorf("%s (%s): got %s (%q: %q), want %s (%q: %q...)",
+					tt.name, checkType, status.Status, status.Assertion, status.Message, tt.wantStatus, tt.wantAssertion, tt.wantMessage)
+			}
+			if status.Certificate == nil || status.Certificate.Issuer != issuer.Issuer.CommonName || !status.Certificate.NotAfter.Equal(issuer.NotAfter) {
+				t.Errorf("%s (%s): certificate = %+v, want the issuer and expiry reported", tt.name, checkType, status.Certificate)
+			}
+		}
+	}
+}
+
+func TestCertificateCheckReportedBeforeAssertions(t *testing.T) {
+	expired := startTLSServer(t, testCA.issue(t, time.Now().Add(-time.Hour)), http.StatusServiceUnavailable)
+	valid := startTLSServer(t, testCA.issue(t, time.Now().Add(90*24*time.Hour)), http.StatusServiceUnavailable)
+
+	checker, err := NewServiceChecker(internal.ServiceConf{Name: "web", API: internal.APIConf{URL: expired}, TLS: internal.TLSConf{Check: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status := checker.CheckStatus(2 * time.Second); status.Assertion != "certificate_expiry" {
+		t.Errorf("expired certificate and 503: assertion %q, want certificate_expiry", status.Assertion)
+	}
+
+	checker, err = NewServiceChecker(internal.ServiceConf{Name: "web", API: internal.APIConf{URL: valid}, TLS: internal.TLSConf{Check: true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	status := checker.CheckStatus(2 * time.Second)
+	if status.Status != internal.StateDown || status.Assertion != "status_code" || status.Certificate == nil {
+		t.Errorf("valid certificate and 503: got %s (%q), certificate %v, want DOWN (status_code) with the certificate", status.Status, status.Assertion, status.Certificate)
+	}
+}
+
+func TestHTTPCheckWithoutCertificateCheck(t *testing.T) {
+	url := startTLSServer(t, testCA.issue(t, time.Now().Add(-time.Hour)), http.StatusOK)
+	checker, err := NewServiceChecker(internal.ServiceConf{Name: "web", API: internal.APIConf{URL: url}})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Without tls.check the request itself verifies the certificate.
+	status := checker.CheckStatus(2 * time.Second)
+	if status.Status != internal.StateDown || status.ErrorClass != "tls" || status.Certificate != nil {
+		t.Errorf("got %s (class %q: %s), want DOWN (class tls)", status.Status, status.ErrorClass, status.Message)
+	}
+}
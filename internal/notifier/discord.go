@@ -0,0 +1,117 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"int-status/internal"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+func init() {
+	Register("discord", func(conf internal.NotifierConf) (Notifier, error) {
+		return NewDiscordNotifier(conf)
+	})
+}
+
+// DiscordNotifier posts embeds through a Discord webhook. Webhooks can only
+// start threads in forum channels, so recoveries reply in the thread of the
+// alert only when Forum is set.
+type DiscordNotifier struct {
+	url     string
+	forum   bool
+	client  *http.Client
+	threads threads
+}
+
+type discordMessage struct {
+	Content    string         `json:"content,omitempty"`
+	ThreadName string         `json:"thread_name,omitempty"`
+	Embeds     []discordEmbed `json:"embeds"`
+}
+
+type discordEmbed struct {
+	Title       string         `json:"title"`
+	Description string         `json:"description,omitempty"`
+	URL         string         `json:"url,omitempty"`
+	Color       int            `json:"color"`
+	Fields      []discordField `json:"fields"`
+	Timestamp   time.Time      `json:"timestamp"`
+}
+
+type discordField struct {
+	Name   string `json:"name"`
+	Value  string `json:"value"`
+	Inline bool   `json:"inline"`
+}
+
+type discordResponse struct {
+	ID        string `json:"id"`
+	ChannelID string `json:"channel_id"`
+}
+
+// NewDiscordNotifier creates a Discord notifier for a webhook URL.
+func NewDiscordNotifier(conf internal.NotifierConf) (*DiscordNotifier, error) {
+	if conf.URL == "" {
+		return nil, errors.New("discord notifier requires url")
+	}
+	if _, err := url.Parse(conf.URL); err != nil {
+		return nil, fmt.Errorf("invalid discord webhook url: %v", err)
+	}
+	return &DiscordNotifier{
+		url:    conf.URL,
+		forum:  conf.Forum,
+		client: &http.Client{},
+	}, nil
+}
+
+func (n *DiscordNotifier) Notify(ctx context.Context, event Event) error {
+	message := discordMessage{Embeds: []discordEmbed{discordEmbedFor(event)}}
+
+	// wait=true makes Discord return the message, including its thread.
+	webhookURL, _ := url.Parse(n.url)
+	query := webhookURL.Query()
+	query.Set("wait", "true")
+	if n.forum {
+		if event.Type.Raises() {
+			message.ThreadName = truncate(headline(event), 100)
+		} else if thread := n.threads.take(event); thread != "" {
+			query.Set("thread_id", thread)
+		}
+	}
+	webhookURL.RawQuery = query.Encode()
+
+	var resp discordResponse
+	if err := postJSON(ctx, n.client, webhookURL.String(), nil, message, &resp); err != nil {
+		return err
+	}
+	if n.forum && event.Type.Raises() {
+		n.threads.set(event, resp.ChannelID)
+	}
+	return nil
+}
+
+func discordEmbedFor(event Event) discordEmbed {
+	fields := []discordField{
+		{Name: "Status", Value: string(event.Status.Status), Inline: true},
+		{Name: "Latency", Value: formatLatency(event.Status.Latency), Inline: true},
+		{Name: sinceLabel(event), Value: fmt.Sprintf("<t:%d:f>", event.Incident.StartTime.Unix()), Inline: true},
+	}
+	if !event.Type.Raises() {
+		fields = append(fields, discordField{Name: "Duration", Value: formatDuration(event.Duration), Inline: true})
+	}
+	if event.LastError != "" {
+		fields = append(fields, discordField{Name: "Last error", Value: "```" + truncate(event.LastError, 1000) + "```"})
+	}
+
+	return discordEmbed{
+		Title:       truncate(headline(event), 256),
+		Description: truncate(event.Service.Description, 4096),
+		URL:         event.DashboardURL,
+		Color:       color(event),
+		Fields:      fields,
+		Timestamp:   event.Status.Timestamp,
+	}
+}
@@ -0,0 +1,159 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"github.com/sirupsen/logrus"
+	"int-status/internal"
+	"sync"
+	"time"
+)
+
+const (
+	defaultTimeout = 10 * time.Second
+
+	// deliveryAttempts is how often a failed delivery is tried in total.
+	deliveryAttempts = 3
+	retryDelay       = 2 * time.Second
+)
+
+// target is a configured notifier.
+type target struct {
+	name     string
+	notifier Notifier
+	timeout  time.Duration
+}
+
+// queueKey identifies the deliveries of one service to one target.
+// @field service The name of the service.
+// @field target  The position of the target among the targets of the service.
+type queueKey struct {
+	service string
+	target  int
+}
+
+// Dispatcher routes events to the global notifiers and to the notifiers of the
+// affected service. Deliveries run in the background so that a slow endpoint
+// never holds up monitoring. Each target receives the events of a service one
+// at a time and in order, so that a recovery never overtakes its alert.
+type Dispatcher struct {
+	global       []target
+	services     map[string][]target
+	dashboardURL string
+	wg           sync.WaitGroup
+
+	mu     sync.Mutex
+	queues map[queueKey][]Event
+}
+
+// NewDispatcher creates the global notifiers and those of every service.
+func NewDispatcher(conf *internal.Config) (*Dispatcher, error) {
+	d := &Dispatcher{
+		services:     make(map[string][]target),
+		dashboardURL: conf.Page.URL,
+		queues:       make(map[queueKey][]Event),
+	}
+
+	var err error
+	if d.global, err = newTargets(conf.Notifiers); err != nil {
+		return nil, err
+	}
+	for _, service := range conf.Services {
+		targets, err := newTargets(service.Notifiers)
+		if err != nil {
+			return nil, fmt.Errorf("service %s: %w", service.Name, err)
+		}
+		if len(targets) > 0 {
+			d.services[service.Name] = targets
+		}
+	}
+	return d, nil
+}
+
+func newTargets(confs []internal.NotifierConf) ([]target, error) {
+	targets := make([]target, 0, len(confs))
+	for _, conf := range confs {
+		notifier, err := New(conf)
+		if err != nil {
+			return nil, err
+		}
+
+		t := target{name: conf.Name, notifier: notifier, timeout: conf.Timeout}
+		if t.name == "" {
+			t.name = conf.Type
+		}
+		if t.timeout <= 0 {
+			t.timeout = defaultTimeout
+		}
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
+
+// Dispatch sends event to every notifier of its service without waiting for
+// the deliveries.
+func (d *Dispatcher) Dispatch(event Event) {
+	event.DashboardURL = d.dashboardURL
+	for i, t := range d.targets(event.Service.Name) {
+		d.enqueue(queueKey{service: event.Service.Name, target: i}, t, event)
+	}
+}
+
+// enqueue adds event to the queue of key, and starts delivering the queue
+// unless that is already under way.
+func (d *Dispatcher) enqueue(key queueKey, t target, event Event) {
+	d.wg.Add(1)
+	d.mu.Lock()
+	pending, running := d.queues[key]
+	d.queues[key] = append(pending, event)
+	d.mu.Unlock()
+	if !running {
+		go d.drain(key, t)
+	}
+}
+
+// drain delivers the events queued under key until none is left.
+func (d *Dispatcher) drain(key queueKey, t target) {
+	for {
+		d.mu.Lock()
+		pending := d.queues[key]
+		if len(pending) == 0 {
+			delete(d.queues, key)
+			d.mu.Unlock()
+			return
+		}
+		d.queues[key] = pending[1:]
+		d.mu.Unlock()
+
+		d.deliver(t, pending[0])
+		d.wg.Done()
+	}
+}
+
+// Wait blocks until every pending delivery has finished.
+func (d *Dispatcher) Wait() {
+	d.wg.Wait()
+}
+
+func (d *Dispatcher) targets(service string) []target {
+	targets := make([]target, 0, len(d.global)+len(d.services[service]))
+	targets = append(targets, d.global...)
+	return append(targets, d.services[service]...)
+}
+
+func (d *Dispatcher) deliver(t target, event Event) {
+	var err error
+	for attempt := 1; attempt <= deliveryAttempts; attempt++ {
+		ctx, cancel := context.WithTimeout(context.Background(), t.timeout)
+		err = t.notifier.Notify(ctx, event)
+		cancel()
+		if err == nil {
+			logrus.Infof("Sent %s alert for %s to %s", event.Type, event.Service.Name, t.name)
+			return
+		}
+		if attempt < deliveryAttempts {
+			time.Sleep(retryDelay)
+		}
+	}
+	logrus.Errorf("Error sending %s alert for %s to %s: %v", event.Type, event.Service.Name, t.name, err)
+}
@@ -0,0 +1,63 @@
+package notifier
+
+import (
+	"encoding/json"
+	"fmt"
+	"int-status/internal"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestDispatcherDeliversInOrder(t *testing.T) {
+	// A Slack Web API that is slow to confirm alerts, so that a recovery sent
+	// right after would overtake its alert if deliveries ran side by side.
+	var mu sync.Mutex
+	var received []slackMessage
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var message slackMessage
+		if err := json.NewDecoder(r.Body).Decode(&message); err != nil {
+			t.Error(err)
+		}
+		if message.ThreadTS == "" {
+			time.Sleep(100 * time.Millisecond)
+		}
+		mu.Lock()
+		received = append(received, message)
+		ts := strconv.Itoa(len(received))
+		mu.Unlock()
+		json.NewEncoder(w).Encode(slackResponse{OK: true, TS: ts})
+	}))
+	defer server.Close()
+
+	d, err := NewDispatcher(&internal.Config{
+		Notifiers: []internal.NotifierConf{{Type: "slack", URL: server.URL, Token: "xoxb-test", Channel: "#alerts"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, service := range []string{"web", "db"} {
+		incident := internal.Incident{ID: service, StartTime: time.Now()}
+		d.Dispatch(Event{Type: EventDown, Service: internal.ServiceConf{Name: service}, Incident: incident})
+		d.Dispatch(Event{Type: EventUp, Service: internal.ServiceConf{Name: service}, Incident: incident})
+	}
+	d.Wait()
+
+	if len(received) != 4 {
+		t.Fatalf("received %d messages, want 4", len(received))
+	}
+	// Services are independent, but each recovery follows its own alert.
+	threads := make(map[string]bool)
+	for i, message := range received {
+		if message.ThreadTS == "" && message.Attachments[0].Color == fmt.Sprintf("#%06X", colorDown) {
+			threads[strconv.Itoa(i+1)] = true
+			continue
+		}
+		if !threads[message.ThreadTS] {
+			t.Errorf("recovery %q was not posted in the thread of its alert", message.Text)
+		}
+	}
+}
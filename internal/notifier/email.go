@@ -0,0 +1,351 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	htmltemplate "html/template"
+	"int-status/internal"
+	"io/fs"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+)
+
+func init() {
+	Register("email", func(conf internal.NotifierConf) (Notifier, error) {
+		return NewEmailNotifier(conf.SMTP)
+	})
+}
+
+// TLS modes of SMTPConf.TLS.
+const (
+	smtpTLSAuto     = ""
+	smtpTLSStartTLS = "starttls"
+	smtpTLSImplicit = "tls"
+	smtpTLSNone     = "none"
+)
+
+const defaultSubjectTemplate = `{{if not .Alert}}[RESOLVED]{{else if .Budget}}[BUDGET]{{else}}[DOWN]{{end}} {{.Headline}}`
+
+const defaultTextTemplate = `{{.Headline}}
+{{with .Event.Service.Description}}
+{{.}}
+{{end}}
+Status:     {{.Event.Status.Status}}
+Latency:    {{.Latency}}
+{{.SinceLabel}}: {{.Since}}
+{{- if not .Alert}}
+Duration:   {{.Duration}}
+{{- end}}
+{{with .Event.LastError}}
+Last error: {{.}}
+{{end}}
+{{- with .Event.DashboardURL}}
+Dashboard: {{.}}
+{{end}}`
+
+const defaultHTMLTemplate = `<!DOCTYPE html>
+<html>
+<body style="font-family: Arial, sans-serif; color: #333;">
+    <h2 style="color: {{.Color}};">{{.Headline}}</h2>
+    {{with .Event.Service.Description}}<p>{{.}}</p>{{end}}
+    <table cellpadding="4">
+        <tr><td><strong>Status</strong></td><td>{{.Event.Status.Status}}</td></tr>
+        <tr><td><strong>Latency</strong></td><td>{{.Latency}}</td></tr>
+        <tr><td><strong>{{.SinceLabel}}</strong></td><td>{{.Since}}</td></tr>
+        {{if not .Alert}}<tr><td><strong>Duration</strong></td><td>{{.Duration}}</td></tr>{{end}}
+    </table>
+    {{with .Event.LastError}}<p><strong>Last error</strong></p><pre>{{.}}</pre>{{end}}
+    {{with .Event.DashboardURL}}<p><a href="{{.}}">Open dashboard</a></p>{{end}}
+</body>
+</html>`
+
+// emailData is what the email templates are executed with.
+// @field Event      The event being sent.
+// @field Down       The same as Alert, for templates written before budget alerts existed.
+// @field Alert      Whether the event raises an alert rather than clearing one.
+// @field Budget     Whether the event is about the error budget rather than an outage.
+// @field Headline   The one-line summary, e.g. "Payments is down".
+// @field Color      The alert colour as a CSS hex value.
+// @field Since      When the outage or the burn started, in UTC.
+// @field SinceLabel "Down since", or "Burning since" for budget events.
+// @field Duration   How long the outage or the burn lasted.
+// @field Latency    The latency of the triggering check.
+type emailData struct {
+	Event      Event
+	Down       bool
+	Alert      bool
+	Budget     bool
+	Headline   string
+	Color      string
+	Since      string
+	SinceLabel string
+	Duration   string
+	Latency    string
+}
+
+// EmailNotifier sends multipart text and HTML emails over SMTP. Recoveries
+// reply to the alert, so mail clients show them in one thread.
+type EmailNotifier struct {
+	conf    internal.SMTPConf
+	from    *mail.Address
+	subject *template.Template
+	text    *template.Template
+	html    *htmltemplate.Template
+}
+
+// NewEmailNotifier creates an email notifier. Templates found in
+// conf.Templates replace the built-in ones.
+func NewEmailNotifier(conf internal.SMTPConf) (*EmailNotifier, error) {
+	if conf.Host == "" {
+		return nil, errors.New("email notifier requires smtp.host")
+	}
+	from, err := mail.ParseAddress(conf.From)
+	if err != nil {
+		return nil, fmt.Errorf("invalid smtp.from: %v", err)
+	}
+	switch conf.TLS {
+	case smtpTLSAuto, smtpTLSStartTLS, smtpTLSImplicit, smtpTLSNone:
+	default:
+		return nil, fmt.Errorf("invalid smtp.tls %q", conf.TLS)
+	}
+	if conf.Port == 0 {
+		conf.Port = 587
+		if conf.TLS == smtpTLSImplicit {
+			conf.Port = 465
+		}
+	}
+
+	if conf.Templates != "" {
+		// A misspelled directory would otherwise silently use the built-in
+		// templates.
+		info, err := os.Stat(conf.Templates)
+		if err != nil {
+			return nil, fmt.Errorf("invalid smtp.templates: %v", err)
+		}
+		if !info.IsDir() {
+			return nil, fmt.Errorf("invalid smtp.templates: %s is not a directory", conf.Templates)
+		}
+	}
+
+	subject, err := readTemplate(conf.Templates, "subject.tmpl", defaultSubjectTemplate)
+	if err != nil {
+		return nil, err
+	}
+	text, err := readTemplate(conf.Templates, "body.txt.tmpl", defaultTextTemplate)
+	if err != nil {
+		return nil, err
+	}
+	html, err := readTemplate(conf.Templates, "body.html.tmpl", defaultHTMLTemplate)
+	if err != nil {
+		return nil, err
+	}
+
+	n := &EmailNotifier{conf: conf, from: from}
+	if n.subject, err = template.New("subject").Parse(subject); err != nil {
+		return nil, fmt.Errorf("invalid subject template: %v", err)
+	}
+	if n.text, err = template.New("text").Parse(text); err != nil {
+		return nil, fmt.Errorf("invalid text template: %v", err)
+	}
+	if n.html, err = htmltemplate.New("html").Parse(html); err != nil {
+		return nil, fmt.Errorf("invalid HTML template: %v", err)
+	}
+	return n, nil
+}
+
+// readTemplate returns the contents of name in dir, or fallback when there is
+// no such file. Any other error, such as missing permissions, is returned so
+// that the templates of the operator are not ignored.
+func readTemplate(dir, name, fallback string) (string, error) {
+	if dir == "" {
+		return fallback, nil
+	}
+	data, err := os.ReadFile(filepath.Join(dir, name))
+	if errors.Is(err, fs.ErrNotExist) {
+		return fallback, nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to read template %s: %v", name, err)
+	}
+	return string(data), nil
+}
+
+func (n *EmailNotifier) Notify(ctx context.Context, event Event) error {
+	recipients := n.recipients(event.Service.Name)
+	if len(recipients) == 0 {
+		return nil
+	}
+
+	message, err := n.message(event, recipients)
+	if err != nil {
+		return err
+	}
+	return n.send(ctx, recipients, message)
+}
+
+// recipients returns the global recipients followed by those of service.
+func (n *EmailNotifier) recipients(service string) []string {
+	recipients := append([]string{}, n.conf.To...)
+	return append(recipients, n.conf.Recipients[service]...)
+}
+
+func (n *EmailNotifier) message(event Event, recipients []string) ([]byte, error) {
+	data := emailData{
+		Event:      event,
+		Down:       event.Type.Raises(),
+		Alert:      event.Type.Raises(),
+		Budget:     event.Type.budget(),
+		SinceLabel: sinceLabel(event),
+		Headline:   headline(event),
+		Color:      fmt.Sprintf("#%06X", color(event)),
+		Since:      event.Incident.StartTime.UTC().Format("2006-01-02 15:04:05 MST"),
+		Duration:   formatDuration(event.Duration),
+		Latency:    formatLatency(event.Status.Latency),
+	}
+
+	var subject, text, html bytes.Buffer
+	if err := n.subject.Execute(&subject, data); err != nil {
+		return nil, fmt.Errorf("failed to render subject: %v", err)
+	}
+	if err := n.text.Execute(&text, data); err != nil {
+		return nil, fmt.Errorf("failed to render text body: %v", err)
+	}
+	if err := n.html.Execute(&html, data); err != nil {
+		return nil, fmt.Errorf("failed to render HTML body: %v", err)
+	}
+
+	var message bytes.Buffer
+	body := multipart.NewWriter(&message)
+
+	alertID := n.messageID(event, event.Type.raisedBy())
+	headers := []string{
+		"From: " + n.from.String(),
+		"To: " + strings.Join(recipients, ", "),
+		"Subject: " + mime.QEncoding.Encode("utf-8", strings.TrimSpace(subject.String())),
+		"Date: " + time.Now().Format(time.RFC1123Z),
+		"Message-ID: " + n.messageID(event, event.Type),
+		"MIME-Version: 1.0",
+		"Content-Type: multipart/alternative; boundary=" + body.Boundary(),
+	}
+	if !event.Type.Raises() {
+		headers = append(headers, "In-Reply-To: "+alertID, "References: "+alertID)
+	}
+	message.WriteString(strings.Join(headers, "\r\n") + "\r\n\r\n")
+
+	for _, part := range []struct {
+		contentType string
+		content     []byte
+	}{
+		{"text/plain; charset=utf-8", text.Bytes()},
+		{"text/html; charset=utf-8", html.Bytes()},
+	} {
+		writer, err := body.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {part.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoder := quotedprintable.NewWriter(writer)
+		if _, err := encoder.Write(part.content); err != nil {
+			return nil, err
+		}
+		if err := encoder.Close(); err != nil {
+			return nil, err
+		}
+	}
+	if err := body.Close(); err != nil {
+		return nil, err
+	}
+	return message.Bytes(), nil
+}
+
+// messageID derives the Message-ID of the email of an incident, so that the
+// recovery can refer to the alert without remembering it.
+func (n *EmailNotifier) messageID(event Event, eventType EventType) string {
+	domain := "tinyping"
+	if at := strings.LastIndex(n.from.Address, "@"); at >= 0 {
+		domain = n.from.Address[at+1:]
+	}
+	return fmt.Sprintf("<%s.%s@%s>", event.Incident.ID, eventType, domain)
+}
+
+func (n *EmailNotifier) send(ctx context.Context, recipients []string, message []byte) error {
+	address := net.JoinHostPort(n.conf.Host, strconv.Itoa(n.conf.Port))
+	tlsConfig := &tls.Config{ServerName: n.conf.Host}
+
+	var conn net.Conn
+	var err error
+	if n.conf.TLS == smtpTLSImplicit {
+		dialer := &tls.Dialer{Config: tlsConfig}
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	} else {
+		var dialer net.Dialer
+		conn, err = dialer.DialContext(ctx, "tcp", address)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to connect to SMTP server: %v", err)
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	client, err := smtp.NewClient(conn, n.conf.Host)
+	if err != nil {
+		conn.Close()
+		return fmt.Errorf("failed to start SMTP session: %v", err)
+	}
+	defer client.Close()
+
+	if n.conf.TLS == smtpTLSAuto || n.conf.TLS == smtpTLSStartTLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(tlsConfig); err != nil {
+				return fmt.Errorf("STARTTLS failed: %v", err)
+			}
+		} else if n.conf.TLS == smtpTLSStartTLS {
+			return errors.New("SMTP server does not support STARTTLS")
+		}
+	}
+
+	if n.conf.Username != "" {
+		auth := smtp.PlainAuth("", n.conf.Username, n.conf.Password, n.conf.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("SMTP authentication failed: %v", err)
+		}
+	}
+
+	if err := client.Mail(n.from.Address); err != nil {
+		return fmt.Errorf("MAIL FROM failed: %v", err)
+	}
+	for _, recipient := range recipients {
+		if err := client.Rcpt(recipient); err != nil {
+			return fmt.Errorf("RCPT TO %s failed: %v", recipient, err)
+		}
+	}
+
+	writer, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("DATA failed: %v", err)
+	}
+	if _, err := writer.Write(message); err != nil {
+		return fmt.Errorf("failed to write message: %v", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("failed to send message: %v", err)
+	}
+	return client.Quit()
+}
@@ -0,0 +1,368 @@
+package notifier
+
+import (
+	"context"
+	"int-status/internal"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net"
+	"net/mail"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpSink is a minimal SMTP server that records the messages it receives.
+type smtpSink struct {
+	listener net.Listener
+	messages chan sinkMessage
+}
+
+type sinkMessage struct {
+	from       string
+	recipients []string
+	data       string
+}
+
+func startSMTPSink(t *testing.T) *smtpSink {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	sink := &smtpSink{listener: listener, messages: make(chan sinkMessage, 10)}
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go sink.serve(conn)
+		}
+	}()
+	t.Cleanup(func() { listener.Close() })
+	return sink
+}
+
+func (s *smtpSink) serve(conn net.Conn) {
+	defer conn.Close()
+	text := textproto.NewConn(conn)
+	text.PrintfLine("220 sink ESMTP")
+
+	var message sinkMessage
+	for {
+		line, err := text.ReadLine()
+		if err != nil {
+			return
+		}
+		command := strings.ToUpper(line)
+		switch {
+		case strings.HasPrefix(command, "EHLO"), strings.HasPrefix(command, "HELO"):
+			text.PrintfLine("250 sink")
+		case strings.HasPrefix(command, "MAIL FROM:"):
+			message.from = strings.Trim(line[len("MAIL FROM:"):], "<> ")
+			text.PrintfLine("250 OK")
+		case strings.HasPrefix(command, "RCPT TO:"):
+			message.recipients = append(message.recipients, strings.Trim(line[len("RCPT TO:"):], "<> "))
+			text.PrintfLine("250 OK")
+		case command == "DATA":
+			text.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+			data, err := io.ReadAll(text.DotReader())
+			if err != nil {
+				return
+			}
+			message.data = string(data)
+			s.messages <- message
+			message = sinkMessage{}
+			text.PrintfLine("250 OK")
+		case command == "QUIT":
+			text.PrintfLine("221 Bye")
+			return
+		default:
+			text.PrintfLine("502 Command not implemented")
+		}
+	}
+}
+
+func (s *smtpSink) receive(t *testing.T) sinkMessage {
+	t.Helper()
+	select {
+	case message := <-s.messages:
+		return message
+	case <-time.After(5 * time.Second):
+		t.Fatal("no message received")
+		return sinkMessage{}
+	}
+}
+
+func (s *smtpSink) conf() internal.SMTPConf {
+	host, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	portNumber, _ := strconv.Atoi(port)
+	return internal.SMTPConf{
+		Host:       host,
+		Port:       portNumber,
+		TLS:        smtpTLSNone,
+		From:       "TinyPing <alerts@example.com>",
+		To:         []string{"ops@example.com"},
+		Recipients: map[string][]string{"결제 API": {"payments@example.com"}},
+	}
+}
+
+// parsedEmail is a received message with its decoded subject and parts.
+type parsedEmail struct {
+	header  mail.Header
+	subject string
+	parts   map[string]string
+}
+
+func parseEmail(t *testing.T, data string) parsedEmail {
+	t.Helper()
+	message, err := mail.ReadMessage(strings.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	subject, err := new(mime.WordDecoder).DecodeHeader(message.Header.Get("Subject"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mediaType, params, err := mime.ParseMediaType(message.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/alternative" {
+		t.Fatalf("Content-Type = %q, want multipart/alternative", message.Header.Get("Content-Type"))
+	}
+	parsed := parsedEmail{header: message.Header, subject: subject, parts: make(map[string]string)}
+	reader := multipart.NewReader(message.Body, params["boundary"])
+	for {
+		part, err := reader.NextRawPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if encoding := part.Header.Get("Content-Transfer-Encoding"); encoding != "quoted-printable" {
+			t.Errorf("Content-Transfer-Encoding = %q, want quoted-printable", encoding)
+		}
+		content, err := io.ReadAll(quotedprintable.NewReader(part))
+		if err != nil {
+			t.Fatal(err)
+		}
+		contentType, _, _ := mime.ParseMediaType(part.Header.Get("Content-Type"))
+		parsed.parts[contentType] = string(content)
+	}
+	return parsed
+}
+
+func TestEmailAlertAndRecoveryThread(t *testing.T) {
+	sink := startSMTPSink(t)
+	n, err := NewEmailNotifier(sink.conf())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2024, 5, 1, 10, 2, 0, 0, time.UTC)
+	down := Event{
+		Type:      EventDown,
+		Service:   internal.ServiceConf{Name: "결제 API", Description: "Card payments"},
+		Status:    internal.Status{Service: "결제 API", Status: internal.StateDown, Latency: 1200},
+		Incident:  internal.Incident{ID: "3f1c9a0e5b7d2c48", Service: "결제 API", StartTime: start},
+		LastError: "got status 503",
+	}
+	if err := n.Notify(context.Background(), down); err != nil {
+		t.Fatal(err)
+	}
+
+	alert := sink.receive(t)
+	if alert.from != "alerts@example.com" {
+		t.Errorf("MAIL FROM = %q", alert.from)
+	}
+	if strings.Join(alert.recipients, ",") != "ops@example.com,payments@example.com" {
+		t.Errorf("RCPT TO = %v, want the global and the service recipients", alert.recipients)
+	}
+	parsed := parseEmail(t, alert.data)
+	if raw := parsed.header.Get("Subject"); !strings.HasPrefix(raw, "=?utf-8?q?") {
+		t.Errorf("raw Subject = %q, want it Q-encoded", raw)
+	}
+	if parsed.subject != "[DOWN] 결제 API is down" {
+		t.Errorf("Subject = %q", parsed.subject)
+	}
+	if text := parsed.parts["text/plain"]; !strings.Contains(text, "Down since: 2024-05-01 10:02:00 UTC") ||
+		!strings.Contains(text, "got status 503") || !strings.Contains(text, "Card payments") {
+		t.Errorf("text part = %q", text)
+	}
+	if html := parsed.parts["text/html"]; !strings.Contains(html, "<pre>got status 503</pre>") ||
+		!strings.Contains(html, "결제 API is down") {
+		t.Errorf("HTML part = %q", html)
+	}
+	alertID := parsed.header.Get("Message-ID")
+	if alertID == "" || parsed.header.Get("In-Reply-To") != "" {
+		t.Errorf("alert Message-ID = %q, In-Reply-To = %q", alertID, parsed.header.Get("In-Reply-To"))
+	}
+
+	up := down
+	up.Type = EventUp
+	up.Status.Status = internal.StateUp
+	up.Duration = 5 * time.Minute
+	if err := n.Notify(context.Background(), up); err != nil {
+		t.Fatal(err)
+	}
+
+	recovery := parseEmail(t, sink.receive(t).data)
+	if recovery.subject != "[RESOLVED] 결제 API has recovered" {
+		t.Errorf("recovery Subject = %q", recovery.subject)
+	}
+	if id := recovery.header.Get("Message-ID"); id == "" || id == alertID {
+		t.Errorf("recovery Message-ID = %q, want one of its own", id)
+	}
+	if recovery.header.Get("In-Reply-To") != alertID || recovery.header.Get("References") != alertID {
+		t.Errorf("In-Reply-To = %q, References = %q, want %q",
+			recovery.header.Get("In-Reply-To"), recovery.header.Get("References"), alertID)
+	}
+	if text := recovery.parts["text/plain"]; !strings.Contains(text, "Duration:   5m") {
+		t.Errorf("recovery text part = %q", text)
+	}
+}
+
+func TestEmailTemplates(t *testing.T) {
+	sink := startSMTPSink(t)
+	dir := t.TempDir()
+	if err := os.WriteFile(filepath.Join(dir, "subject.tmpl"), []byte("Alert: {{.Event.Service.Name}}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	conf := sink.conf()
+	conf.Templates = dir
+	n, err := NewEmailNotifier(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := Event{
+		Type:     EventDown,
+		Service:  internal.ServiceConf{Name: "web"},
+		Incident: internal.Incident{ID: "1", StartTime: time.Now()},
+	}
+	if err := n.Notify(context.Background(), event); err != nil {
+		t.Fatal(err)
+	}
+
+	// The subject is replaced, the bodies without a file keep the default.
+	parsed := parseEmail(t, sink.receive(t).data)
+	if parsed.subject != "Alert: web" {
+		t.Errorf("Subject = %q", parsed.subject)
+	}
+	if !strings.Contains(parsed.parts["text/plain"], "web is down") {
+		t.Errorf("text part = %q", parsed.parts["text/plain"])
+	}
+}
+
+func TestNewEmailNotifierReportsTemplateErrors(t *testing.T) {
+	conf := internal.SMTPConf{Host: "127.0.0.1", From: "alerts@example.com", TLS: smtpTLSNone}
+
+	missing := conf
+	missing.Templates = filepath.Join(t.TempDir(), "mial")
+	if _, err := NewEmailNotifier(missing); err == nil {
+		t.Error("a missing templates directory was accepted")
+	}
+
+	notDir := conf
+	notDir.Templates = filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(notDir.Templates, nil, 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewEmailNotifier(notDir); err == nil {
+		t.Error("a file as templates directory was accepted")
+	}
+
+	dirAsFile := conf
+	dirAsFile.Templates = t.TempDir()
+	if err := os.Mkdir(filepath.Join(dirAsFile.Templates, "body.txt.tmpl"), 0o700); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewEmailNotifier(dirAsFile); err == nil || !strings.Contains(err.Error(), "body.txt.tmpl") {
+		t.Errorf("a directory in place of a template: err = %v, want it reported", err)
+	}
+
+	invalid := conf
+	invalid.Templates = t.TempDir()
+	if err := os.WriteFile(filepath.Join(invalid.Templates, "body.html.tmpl"), []byte("{{if}}"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewEmailNotifier(invalid); err == nil {
+		t.Error("an invalid template was accepted")
+	}
+}
+
+func TestNewEmailNotifierReportsUnreadableTemplate(t *testing.T) {
+	if os.Geteuid() == 0 {
+		t.Skip("file permissions do not apply to root")
+	}
+	conf := internal.SMTPConf{Host: "127.0.0.1", From: "alerts@example.com", TLS: smtpTLSNone, Templates: t.TempDir()}
+	path := filepath.Join(conf.Templates, "subject.tmpl")
+	if err := os.WriteFile(path, []byte("x"), 0o000); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := NewEmailNotifier(conf); err == nil {
+		t.Error("an unreadable template was ignored")
+	}
+}
+
+func TestEmailBudgetEvents(t *testing.T) {
+	sink := startSMTPSink(t)
+	dir := t.TempDir()
+	// A template written before budget alerts existed.
+	subject := `{{if .Down}}ALERT{{else}}RESOLVED{{end}} {{if .Budget}}budget{{else}}outage{{end}}: {{.Event.Service.Name}}`
+	if err := os.WriteFile(filepath.Join(dir, "subject.tmpl"), []byte(subject), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	conf := sink.conf()
+	conf.Templates = dir
+	n, err := NewEmailNotifier(conf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, test := range []struct {
+		event EventType
+		want  string
+	}{
+		{EventDown, "ALERT outage: web"},
+		{EventUp, "RESOLVED outage: web"},
+		{EventBudgetBurn, "ALERT budget: web"},
+		{EventBudgetRecovered, "RESOLVED budget: web"},
+	} {
+		event := Event{
+			Type:     test.event,
+			Service:  internal.ServiceConf{Name: "web"},
+			Incident: internal.Incident{ID: "1", StartTime: time.Now()},
+		}
+		if err := n.Notify(context.Background(), event); err != nil {
+			t.Fatal(err)
+		}
+		if parsed := parseEmail(t, sink.receive(t).data); parsed.subject != test.want {
+			t.Errorf("%s: Subject = %q, want %q", test.event, parsed.subject, test.want)
+		}
+	}
+
+	// The default subject tells budget alerts apart from outages.
+	n, err = NewEmailNotifier(sink.conf())
+	if err != nil {
+		t.Fatal(err)
+	}
+	burn := Event{
+		Type:     EventBudgetBurn,
+		Service:  internal.ServiceConf{Name: "web"},
+		Incident: internal.Incident{ID: "2", StartTime: time.Now()},
+	}
+	if err := n.Notify(context.Background(), burn); err != nil {
+		t.Fatal(err)
+	}
+	if parsed := parseEmail(t, sink.receive(t).data); parsed.subject != "[BUDGET] web is burning its error budget" {
+		t.Errorf("burn Subject = %q", parsed.subject)
+	}
+}
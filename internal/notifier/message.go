@@ -0,0 +1,102 @@
+package notifier
+
+import (
+	"fmt"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// Colours of alert messages in chat notifiers.
+const (
+	colorDown = 0xE01E5A
+	colorUp   = 0x2EB67D
+)
+
+// headline is the one-line summary of an event.
+func headline(event Event) string {
+	switch event.Type {
+	case EventUp:
+		return event.Service.Name + " has recovered"
+	case EventBudgetBurn:
+		return event.Service.Name + " is burning its error budget"
+	case EventBudgetRecovered:
+		return event.Service.Name + " is no longer burning its error budget"
+	default:
+		return event.Service.Name + " is down"
+	}
+}
+
+// sinceLabel names the start of the incident of an event.
+func sinceLabel(event Event) string {
+	if event.Type.budget() {
+		return "Burning since"
+	}
+	return "Down since"
+}
+
+func color(event Event) int {
+	if event.Type.Raises() {
+		return colorDown
+	}
+	return colorUp
+}
+
+func formatLatency(ms int64) string {
+	return fmt.Sprintf("%d ms", ms)
+}
+
+// formatDuration rounds d to whole seconds, e.g. "5m30s".
+func formatDuration(d time.Duration) string {
+	return d.Round(time.Second).String()
+}
+
+// truncate shortens s to at most n bytes, marking the cut with an ellipsis.
+// It never cuts a character in half.
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	cut := n - 3
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return s[:cut] + "..."
+}
+
+// threads remembers where the alert of each open incident was posted, so that
+// the recovery can reply to it.
+type threads struct {
+	mu  sync.Mutex
+	ids map[string]string
+}
+
+func (t *threads) set(event Event, id string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.ids == nil {
+		t.ids = make(map[string]string)
+	}
+	t.ids[event.Incident.ID] = id
+}
+
+// take returns the thread of the incident of event and forgets it.
+func (t *threads) take(event Event) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	id := t.ids[event.Incident.ID]
+	delete(t.ids, event.Incident.ID)
+	return id
+}
+
+// dedupKey identifies the incident of an event to incident management tools,
+// so that the recovery resolves the alert opened for the same incident. A
+// budget alert often starts with the same check as an outage, so its key is
+// kept apart.
+func dedupKey(event Event) string {
+	key := event.Service.Name + "@" + event.Incident.StartTime.UTC().Format(time.RFC3339)
+	if event.Type.budget() {
+		key += "/budget"
+	}
+	return key
+}
@@ -0,0 +1,26 @@
+package notifier
+
+import "testing"
+
+func TestTruncate(t *testing.T) {
+	for _, test := range []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"short", 10, "short"},
+		{"exactly10!", 10, "exactly10!"},
+		{"a longer message", 10, "a longe..."},
+		// "é" is two bytes; it is dropped rather than cut in half.
+		{"héhéhéhé", 8, "héh..."},
+		{"日本語のテキスト", 10, "日本..."},
+	} {
+		got := truncate(test.s, test.n)
+		if got != test.want {
+			t.Errorf("truncate(%q, %d) = %q, want %q", test.s, test.n, got, test.want)
+		}
+		if len(got) > test.n {
+			t.Errorf("truncate(%q, %d) is %d bytes long", test.s, test.n, len(got))
+		}
+	}
+}
@@ -0,0 +1,117 @@
+package notifier
+
+import (
+	"context"
+	"fmt"
+	"int-status/internal"
+	"sort"
+	"sync"
+	"time"
+)
+
+// EventType is the kind of state transition an Event reports.
+type EventType string
+
+const (
+	// EventDown is sent when a service goes DOWN and an incident opens.
+	EventDown EventType = "down"
+	// EventUp is sent when a DOWN service recovers and its incident resolves.
+	EventUp EventType = "up"
+	// EventBudgetBurn is sent when a service burns its error budget too fast.
+	EventBudgetBurn EventType = "budget_burn"
+	// EventBudgetRecovered is sent when the burn of EventBudgetBurn has slowed down.
+	EventBudgetRecovered EventType = "budget_recovered"
+)
+
+// Raises reports whether the event raises an alert rather than clearing one.
+func (t EventType) Raises() bool {
+	return t == EventDown || t == EventBudgetBurn
+}
+
+// budget reports whether the event is about the error budget of the service
+// rather than an outage.
+func (t EventType) budget() bool {
+	return t == EventBudgetBurn || t == EventBudgetRecovered
+}
+
+// raisedBy returns the type of the event that raised the alert an event
+// belongs to.
+func (t EventType) raisedBy() EventType {
+	switch t {
+	case EventUp:
+		return EventDown
+	case EventBudgetRecovered:
+		return EventBudgetBurn
+	default:
+		return t
+	}
+}
+
+// Event describes a state transition of a service. Budget events carry an
+// incident too: it spans the time the budget burned too fast.
+// @field Type          Whether the service went down or recovered, or its error budget started or stopped burning too fast.
+// @field Service       The configuration of the affected service.
+// @field Status       The status that caused the transition.
+// @field Incident     The incident opened or resolved by the transition.
+// @field Duration     How long the service has been down; zero for alerts being raised.
+// @field LastError    The most recent failure reason of the incident, or the burn rates of budget events.
+// @field DashboardURL The public URL of the dashboard, if configured.
+type Event struct {
+	Type         EventType
+	Service      internal.ServiceConf
+	Status       internal.Status
+	Incident     internal.Incident
+	Duration     time.Duration
+	LastError    string
+	DashboardURL string
+}
+
+// Notifier delivers events to a single destination.
+type Notifier interface {
+	Notify(ctx context.Context, event Event) error
+}
+
+// Factory creates a Notifier from its configuration.
+type Factory func(conf internal.NotifierConf) (Notifier, error)
+
+var (
+	typesMu sync.RWMutex
+	types   = make(map[string]Factory)
+)
+
+// Register makes a notifier type available under name. It is meant to be
+// called from the init function of the file implementing the notifier.
+func Register(name string, factory Factory) {
+	typesMu.Lock()
+	defer typesMu.Unlock()
+
+	if _, exists := types[name]; exists {
+		panic(fmt.Sprintf("notifier: type %q registered twice", name))
+	}
+	types[name] = factory
+}
+
+// New creates the notifier selected by conf.Type.
+func New(conf internal.NotifierConf) (Notifier, error) {
+	typesMu.RLock()
+	factory, ok := types[conf.Type]
+	typesMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown notifier type %q (available: %v)", conf.Type, Types())
+	}
+	return factory(conf)
+}
+
+// Types returns the names of all registered notifier types.
+func Types() []string {
+	typesMu.RLock()
+	defer typesMu.RUnlock()
+
+	names := make([]string, 0, len(types))
+	for name := range types {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,122 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"int-status/internal"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+func init() {
+	Register("opsgenie", func(conf internal.NotifierConf) (Notifier, error) {
+		return NewOpsgenieNotifier(conf)
+	})
+}
+
+var opsgenieURLs = map[string]string{
+	"":   "https://api.opsgenie.com",
+	"us": "https://api.opsgenie.com",
+	"eu": "https://api.eu.opsgenie.com",
+}
+
+var opsgeniePriorities = map[string]bool{"P1": true, "P2": true, "P3": true, "P4": true, "P5": true}
+
+// OpsgenieNotifier creates an Opsgenie alert when a service goes down and
+// closes it when the service recovers.
+type OpsgenieNotifier struct {
+	url    string
+	conf   internal.OpsgenieConf
+	client *http.Client
+}
+
+type opsgenieAlert struct {
+	Message     string            `json:"message"`
+	Alias       string            `json:"alias"`
+	Description string            `json:"description,omitempty"`
+	Priority    string            `json:"priority"`
+	Source      string            `json:"source"`
+	Entity      string            `json:"entity"`
+	Tags        []string          `json:"tags"`
+	Details     map[string]string `json:"details"`
+}
+
+type opsgenieClose struct {
+	Source string `json:"source"`
+	Note   string `json:"note"`
+}
+
+// NewOpsgenieNotifier creates an Opsgenie notifier. conf.URL overrides the API
+// base URL selected by the region.
+func NewOpsgenieNotifier(conf internal.NotifierConf) (*OpsgenieNotifier, error) {
+	og := conf.Opsgenie
+	if og.APIKey == "" && len(og.Services) == 0 {
+		return nil, errors.New("opsgenie notifier requires opsgenie.api_key")
+	}
+	priorities := []string{og.Priority}
+	for _, route := range og.Services {
+		priorities = append(priorities, route.Priority)
+	}
+	for _, priority := range priorities {
+		if priority != "" && !opsgeniePriorities[priority] {
+			return nil, fmt.Errorf("invalid opsgenie priority %q", priority)
+		}
+	}
+
+	n := &OpsgenieNotifier{url: conf.URL, conf: og, client: &http.Client{}}
+	if n.url == "" {
+		base, ok := opsgenieURLs[og.Region]
+		if !ok {
+			return nil, fmt.Errorf("invalid opsgenie region %q", og.Region)
+		}
+		n.url = base
+	}
+	n.url = strings.TrimSuffix(n.url, "/")
+	return n, nil
+}
+
+// route returns the API key and priority of service.
+func (n *OpsgenieNotifier) route(service string) (string, string) {
+	apiKey, priority := n.conf.APIKey, n.conf.Priority
+	if route, ok := n.conf.Services[service]; ok {
+		if route.APIKey != "" {
+			apiKey = route.APIKey
+		}
+		if route.Priority != "" {
+			priority = route.Priority
+		}
+	}
+	if priority == "" {
+		priority = "P1"
+	}
+	return apiKey, priority
+}
+
+func (n *OpsgenieNotifier) Notify(ctx context.Context, event Event) error {
+	apiKey, priority := n.route(event.Service.Name)
+	if apiKey == "" {
+		return nil
+	}
+	headers := map[string]string{"Authorization": "GenieKey " + apiKey}
+	alias := dedupKey(event)
+
+	if !event.Type.Raises() {
+		closeURL := fmt.Sprintf("%s/v2/alerts/%s/close?identifierType=alias", n.url, url.PathEscape(alias))
+		note := fmt.Sprintf("%s after %s.", headline(event), formatDuration(event.Duration))
+		return postJSON(ctx, n.client, closeURL, headers, opsgenieClose{Source: "TinyPing", Note: note}, nil)
+	}
+
+	alert := opsgenieAlert{
+		Message:     truncate(headline(event), 130),
+		Alias:       alias,
+		Description: truncate(event.LastError, 15000),
+		Priority:    priority,
+		Source:      "TinyPing",
+		Entity:      event.Service.Name,
+		Tags:        []string{"tinyping"},
+		Details:     incidentDetails(event),
+	}
+	return postJSON(ctx, n.client, n.url+"/v2/alerts", headers, alert, nil)
+}
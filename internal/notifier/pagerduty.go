@@ -0,0 +1,140 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"int-status/internal"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+func init() {
+	Register("pagerduty", func(conf internal.NotifierConf) (Notifier, error) {
+		return NewPagerDutyNotifier(conf)
+	})
+}
+
+const pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+var pagerDutySeverities = map[string]bool{"critical": true, "error": true, "warning": true, "info": true}
+
+// PagerDutyNotifier triggers a PagerDuty alert when a service goes down and
+// resolves it when the service recovers.
+type PagerDutyNotifier struct {
+	url    string
+	conf   internal.PagerDutyConf
+	client *http.Client
+}
+
+type pagerDutyEvent struct {
+	RoutingKey  string            `json:"routing_key"`
+	EventAction string            `json:"event_action"`
+	DedupKey    string            `json:"dedup_key"`
+	Payload     *pagerDutyPayload `json:"payload,omitempty"`
+	Links       []pagerDutyLink   `json:"links,omitempty"`
+}
+
+type pagerDutyPayload struct {
+	Summary       string            `json:"summary"`
+	Source        string            `json:"source"`
+	Severity      string            `json:"severity"`
+	Timestamp     time.Time         `json:"timestamp"`
+	Component     string            `json:"component"`
+	CustomDetails map[string]string `json:"custom_details"`
+}
+
+type pagerDutyLink struct {
+	Href string `json:"href"`
+	Text string `json:"text"`
+}
+
+// NewPagerDutyNotifier creates a PagerDuty notifier. conf.URL overrides the
+// Events API endpoint.
+func NewPagerDutyNotifier(conf internal.NotifierConf) (*PagerDutyNotifier, error) {
+	pd := conf.PagerDuty
+	if pd.RoutingKey == "" && len(pd.Services) == 0 {
+		return nil, errors.New("pagerduty notifier requires pagerduty.routing_key")
+	}
+	severities := []string{pd.Severity}
+	for _, route := range pd.Services {
+		severities = append(severities, route.Severity)
+	}
+	for _, severity := range severities {
+		if severity != "" && !pagerDutySeverities[severity] {
+			return nil, fmt.Errorf("invalid pagerduty severity %q", severity)
+		}
+	}
+
+	n := &PagerDutyNotifier{url: conf.URL, conf: pd, client: &http.Client{}}
+	if n.url == "" {
+		n.url = pagerDutyEventsURL
+	}
+	return n, nil
+}
+
+// route returns the routing key and severity of service.
+func (n *PagerDutyNotifier) route(service string) (string, string) {
+	routingKey, severity := n.conf.RoutingKey, n.conf.Severity
+	if route, ok := n.conf.Services[service]; ok {
+		if route.RoutingKey != "" {
+			routingKey = route.RoutingKey
+		}
+		if route.Severity != "" {
+			severity = route.Severity
+		}
+	}
+	if severity == "" {
+		severity = "critical"
+	}
+	return routingKey, severity
+}
+
+func (n *PagerDutyNotifier) Notify(ctx context.Context, event Event) error {
+	routingKey, severity := n.route(event.Service.Name)
+	if routingKey == "" {
+		return nil
+	}
+
+	message := pagerDutyEvent{
+		RoutingKey:  routingKey,
+		EventAction: "resolve",
+		DedupKey:    dedupKey(event),
+	}
+	if event.Type.Raises() {
+		message.EventAction = "trigger"
+		message.Payload = &pagerDutyPayload{
+			Summary:       truncate(headline(event)+": "+event.LastError, 1024),
+			Source:        event.Service.Name,
+			Severity:      severity,
+			Timestamp:     event.Incident.StartTime,
+			Component:     event.Service.Name,
+			CustomDetails: incidentDetails(event),
+		}
+		if event.DashboardURL != "" {
+			message.Links = []pagerDutyLink{{Href: event.DashboardURL, Text: "Status dashboard"}}
+		}
+	}
+	return postJSON(ctx, n.client, n.url, nil, message, nil)
+}
+
+// incidentDetails are the fields attached to alerts in incident management tools.
+func incidentDetails(event Event) map[string]string {
+	details := map[string]string{
+		"incident_id":    event.Incident.ID,
+		"status":         string(event.Status.Status),
+		"latency_ms":     strconv.FormatInt(event.Status.Latency, 10),
+		"incident_start": event.Incident.StartTime.UTC().Format(time.RFC3339),
+	}
+	if event.Service.Description != "" {
+		details["description"] = event.Service.Description
+	}
+	if event.LastError != "" {
+		details["last_error"] = event.LastError
+	}
+	if event.DashboardURL != "" {
+		details["dashboard_url"] = event.DashboardURL
+	}
+	return details
+}
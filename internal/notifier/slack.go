@@ -0,0 +1,175 @@
+package notifier
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"int-status/internal"
+	"net/http"
+)
+
+func init() {
+	Register("slack", func(conf internal.NotifierConf) (Notifier, error) {
+		return NewSlackNotifier(conf)
+	})
+}
+
+// slackPostMessageURL is the Web API method used when a bot token is configured.
+const slackPostMessageURL = "https://slack.com/api/chat.postMessage"
+
+// SlackNotifier posts Block Kit messages to Slack. With an incoming webhook
+// every event is a new message; with a bot token and channel the recovery is
+// posted as a reply to the alert.
+type SlackNotifier struct {
+	url     string
+	token   string
+	channel string
+	client  *http.Client
+	threads threads
+}
+
+type slackMessage struct {
+	Channel        string            `json:"channel,omitempty"`
+	Text           string            `json:"text"`
+	ThreadTS       string            `json:"thread_ts,omitempty"`
+	ReplyBroadcast bool              `json:"reply_broadcast,omitempty"`
+	Attachments    []slackAttachment `json:"attachments"`
+}
+
+// slackAttachment carries the blocks so that the message gets a colour bar.
+type slackAttachment struct {
+	Color  string       `json:"color"`
+	Blocks []slackBlock `json:"blocks"`
+}
+
+type slackBlock struct {
+	Type     string         `json:"type"`
+	Text     *slackText     `json:"text,omitempty"`
+	Fields   []slackText    `json:"fields,omitempty"`
+	Elements []slackElement `json:"elements,omitempty"`
+}
+
+type slackText struct {
+	Type  string `json:"type"`
+	Text  string `json:"text"`
+	Emoji bool   `json:"emoji,omitempty"`
+}
+
+type slackElement struct {
+	Type string    `json:"type"`
+	Text slackText `json:"text"`
+	URL  string    `json:"url"`
+}
+
+type slackResponse struct {
+	OK    bool   `json:"ok"`
+	TS    string `json:"ts"`
+	Error string `json:"error"`
+}
+
+// NewSlackNotifier creates a Slack notifier for an incoming webhook URL, or
+// for a bot token and channel.
+func NewSlackNotifier(conf internal.NotifierConf) (*SlackNotifier, error) {
+	n := &SlackNotifier{
+		url:     conf.URL,
+		token:   conf.Token,
+		channel: conf.Channel,
+		client:  &http.Client{},
+	}
+	if n.token != "" {
+		if n.channel == "" {
+			return nil, errors.New("slack notifier with a token requires channel")
+		}
+		if n.url == "" {
+			n.url = slackPostMessageURL
+		}
+	}
+	if n.url == "" {
+		return nil, errors.New("slack notifier requires url or token")
+	}
+	return n, nil
+}
+
+func (n *SlackNotifier) Notify(ctx context.Context, event Event) error {
+	message := slackMessage{
+		Text: headline(event),
+		Attachments: []slackAttachment{{
+			Color:  fmt.Sprintf("#%06X", color(event)),
+			Blocks: slackBlocks(event),
+		}},
+	}
+
+	if n.token == "" {
+		return postJSON(ctx, n.client, n.url, nil, message, nil)
+	}
+
+	message.Channel = n.channel
+	if !event.Type.Raises() {
+		message.ThreadTS = n.threads.take(event)
+		message.ReplyBroadcast = message.ThreadTS != ""
+	}
+
+	var resp slackResponse
+	headers := map[string]string{"Authorization": "Bearer " + n.token}
+	if err := postJSON(ctx, n.client, n.url, headers, message, &resp); err != nil {
+		return err
+	}
+	if !resp.OK {
+		return fmt.Errorf("slack error: %s", resp.Error)
+	}
+	if event.Type.Raises() {
+		n.threads.set(event, resp.TS)
+	}
+	return nil
+}
+
+func slackBlocks(event Event) []slackBlock {
+	icon := ":red_circle:"
+	if !event.Type.Raises() {
+		icon = ":large_green_circle:"
+	}
+
+	blocks := []slackBlock{{
+		Type: "header",
+		Text: &slackText{Type: "plain_text", Text: truncate(icon+" "+headline(event), 150), Emoji: true},
+	}}
+	if description := event.Service.Description; description != "" {
+		blocks = append(blocks, slackBlock{
+			Type: "section",
+			Text: &slackText{Type: "mrkdwn", Text: truncate(description, 3000)},
+		})
+	}
+
+	fields := []slackText{
+		{Type: "mrkdwn", Text: "*Status*\n" + string(event.Status.Status)},
+		{Type: "mrkdwn", Text: "*Latency*\n" + formatLatency(event.Status.Latency)},
+		{Type: "mrkdwn", Text: "*" + sinceLabel(event) + "*\n" + slackDate(event.Incident.StartTime.Unix())},
+	}
+	if !event.Type.Raises() {
+		fields = append(fields, slackText{Type: "mrkdwn", Text: "*Duration*\n" + formatDuration(event.Duration)})
+	}
+	blocks = append(blocks, slackBlock{Type: "section", Fields: fields})
+
+	if event.LastError != "" {
+		blocks = append(blocks, slackBlock{
+			Type: "section",
+			Text: &slackText{Type: "mrkdwn", Text: "*Last error*\n```" + truncate(event.LastError, 500) + "```"},
+		})
+	}
+	if event.DashboardURL != "" {
+		blocks = append(blocks, slackBlock{
+			Type: "actions",
+			Elements: []slackElement{{
+				Type: "button",
+				Text: slackText{Type: "plain_text", Text: "Open dashboard"},
+				URL:  event.DashboardURL,
+			}},
+		})
+	}
+	return blocks
+}
+
+// slackDate renders a Unix time in the time zone of each reader.
+func slackDate(unix int64) string {
+	return fmt.Sprintf("<!date^%d^{date_short_pretty} {time_secs}|%d>", unix, unix)
+}
@@ -0,0 +1,108 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"int-status/internal"
+	"io"
+	"net/http"
+	"time"
+)
+
+func init() {
+	Register("webhook", func(conf internal.NotifierConf) (Notifier, error) {
+		return NewWebhookNotifier(conf)
+	})
+}
+
+// WebhookNotifier POSTs every event as JSON to a URL.
+type WebhookNotifier struct {
+	url     string
+	headers map[string]string
+	client  *http.Client
+}
+
+// webhookPayload is the body sent by WebhookNotifier.
+type webhookPayload struct {
+	Event           EventType      `json:"event"`
+	Service         string         `json:"service"`
+	Description     string         `json:"description,omitempty"`
+	Status          internal.State `json:"status"`
+	Timestamp       time.Time      `json:"timestamp"`
+	LatencyMs       int64          `json:"latency_ms"`
+	IncidentID      string         `json:"incident_id"`
+	IncidentStart   time.Time      `json:"incident_start"`
+	DurationSeconds int64          `json:"duration_seconds"`
+	Cause           string         `json:"cause,omitempty"`
+	LastError       string         `json:"last_error,omitempty"`
+	DashboardURL    string         `json:"dashboard_url,omitempty"`
+}
+
+// NewWebhookNotifier creates a webhook notifier for conf.URL.
+func NewWebhookNotifier(conf internal.NotifierConf) (*WebhookNotifier, error) {
+	if conf.URL == "" {
+		return nil, errors.New("webhook notifier requires url")
+	}
+	return &WebhookNotifier{
+		url:     conf.URL,
+		headers: conf.Headers,
+		client:  &http.Client{},
+	}, nil
+}
+
+func (n *WebhookNotifier) Notify(ctx context.Context, event Event) error {
+	payload := webhookPayload{
+		Event:           event.Type,
+		Service:         event.Service.Name,
+		Description:     event.Service.Description,
+		Status:          event.Status.Status,
+		Timestamp:       event.Status.Timestamp,
+		LatencyMs:       event.Status.Latency,
+		IncidentID:      event.Incident.ID,
+		IncidentStart:   event.Incident.StartTime,
+		DurationSeconds: int64(event.Duration / time.Second),
+		Cause:           event.Incident.Cause,
+		LastError:       event.LastError,
+		DashboardURL:    event.DashboardURL,
+	}
+	return postJSON(ctx, n.client, n.url, n.headers, payload, nil)
+}
+
+// postJSON POSTs body as JSON to url and treats any non-2xx response as an
+// error. If out is not nil, the JSON response is decoded into it.
+func postJSON(ctx context.Context, client *http.Client, url string, headers map[string]string, body, out interface{}) error {
+	data, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("failed to marshal request: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected response %d: %s", resp.StatusCode, bytes.TrimSpace(snippet))
+	}
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode response: %v", err)
+	}
+	return nil
+}
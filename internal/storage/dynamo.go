@@ -2,11 +2,12 @@ package storage
 
 import (
 	"context"
+	"encoding/base64"
+	"errors"
 	"fmt"
 	"github.com/aws/aws-sdk-go-v2/config"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"int-status/internal"
-	"sort"
 	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
@@ -14,6 +15,12 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
+func init() {
+	Register("dynamodb", func(conf internal.StorageConf) (Storage, error) {
+		return NewDynamoDBStorage(conf.DynamoDB.Region, conf.DynamoDB.Table)
+	})
+}
+
 type DynamoDBStorage struct {
 	client *dynamodb.Client
 	table  string
@@ -65,10 +72,8 @@ func (s *DynamoDBStorage) UpdateHistory(statuses []internal.Status) error {
 	return nil
 }
 
-func (s *DynamoDBStorage) GetDailyHistory(service string) ([]internal.Status, error) {
-	today := time.Now().Format("2006-01-02")
-
-	result, err := s.client.Query(context.TODO(), &dynamodb.QueryInput{
+func (s *DynamoDBStorage) GetHistory(service string, query HistoryQuery) (HistoryPage, error) {
+	input := &dynamodb.QueryInput{
 		TableName:              aws.String(s.table),
 		KeyConditionExpression: aws.String("service = :service AND #timestamp BETWEEN :start AND :end"),
 		ExpressionAttributeNames: map[string]string{
@@ -76,113 +81,441 @@ func (s *DynamoDBStorage) GetDailyHistory(service string) ([]internal.Status, er
 		},
 		ExpressionAttributeValues: map[string]types.AttributeValue{
 			":service": &types.AttributeValueMemberS{Value: service},
-			":start":   &types.AttributeValueMemberS{Value: today + "T00:00:00+09:00"},
-			":end":     &types.AttributeValueMemberS{Value: today + "T23:59:59+09:00"},
+			":start":   &types.AttributeValueMemberS{Value: formatTimestamp(query.From)},
+			":end":     &types.AttributeValueMemberS{Value: formatTimestamp(query.To)},
 		},
 		ScanIndexForward: aws.Bool(false),
-		Limit:            aws.Int32(10),
-	})
-
-	if err != nil {
-		return nil, fmt.Errorf("failed to query DynamoDB: %v", err)
+		Limit:            aws.Int32(int32(pageLimit(query))),
 	}
 
-	var statuses []internal.Status
-	for _, item := range result.Items {
-		var status internal.Status
-		err := attributevalue.UnmarshalMap(item, &status)
+	if query.Cursor != "" {
+		timestamp, err := base64.RawURLEncoding.DecodeString(query.Cursor)
 		if err != nil {
-			return nil, err
+			return HistoryPage{}, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+		}
+		// Cursors are sort keys; anything else would fail inside DynamoDB.
+		if _, err := time.Parse(time.RFC3339, string(timestamp)); err != nil {
+			return HistoryPage{}, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+		}
+		input.ExclusiveStartKey = map[string]types.AttributeValue{
+			"service":   &types.AttributeValueMemberS{Value: service},
+			"timestamp": &types.AttributeValueMemberS{Value: string(timestamp)},
 		}
-		statuses = append(statuses, status)
 	}
 
-	if len(statuses) == 0 {
-		return nil, fmt.Errorf("no status data found. date = %s", today)
+	result, err := s.client.Query(context.TODO(), input)
+	if err != nil {
+		return HistoryPage{}, fmt.Errorf("failed to query DynamoDB: %v", err)
 	}
 
-	for i, j := 0, len(statuses)-1; i < j; i, j = i+1, j-1 {
-		statuses[i], statuses[j] = statuses[j], statuses[i]
+	statuses, err := unmarshalStatuses(result.Items)
+	if err != nil {
+		return HistoryPage{}, err
 	}
+	reverse(statuses)
 
-	return statuses, nil
+	page := HistoryPage{Statuses: statuses}
+	if key, ok := result.LastEvaluatedKey["timestamp"].(*types.AttributeValueMemberS); ok {
+		page.NextCursor = base64.RawURLEncoding.EncodeToString([]byte(key.Value))
+	}
+	return page, nil
 }
 
-func (s *DynamoDBStorage) GetDailyIncidents(service string) ([]internal.Incident, error) {
-	today := time.Now().Format("2006-01-02")
-
-	result, err := s.client.Query(context.TODO(), &dynamodb.QueryInput{
+// GetUptime projects only the attributes it needs, since a range may span
+// months of statuses.
+func (s *DynamoDBStorage) GetUptime(service string, froms []time.Time, to time.Time, maxGap time.Duration) ([]internal.Uptime, error) {
+	counter := newUptimeCounter(froms, to, maxGap)
+	paginator := dynamodb.NewQueryPaginator(s.client, &dynamodb.QueryInput{
 		TableName:              aws.String(s.table),
 		KeyConditionExpression: aws.String("service = :service AND #timestamp BETWEEN :start AND :end"),
-		FilterExpression:       aws.String("#status = :status"),
+		ProjectionExpression:   aws.String("#timestamp, #status, derived"),
 		ExpressionAttributeNames: map[string]string{
 			"#timestamp": "timestamp",
 			"#status":    "status",
 		},
 		ExpressionAttributeValues: map[string]types.AttributeValue{
 			":service": &types.AttributeValueMemberS{Value: service},
-			":status":  &types.AttributeValueMemberS{Value: "DOWN"},
-			":start":   &types.AttributeValueMemberS{Value: today + "T00:00:00+09:00"},
-			":end":     &types.AttributeValueMemberS{Value: today + "T23:59:59+09:00"},
+			":start":   &types.AttributeValueMemberS{Value: formatTimestamp(counter.queryStart())},
+			":end":     &types.AttributeValueMemberS{Value: formatTimestamp(to)},
 		},
 	})
 
+	for paginator.HasMorePages() {
+		result, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("failed to query DynamoDB: %v", err)
+		}
+		statuses, err := unmarshalStatuses(result.Items)
+		if err != nil {
+			return nil, err
+		}
+		for _, status := range statuses {
+			counter.add(status)
+		}
+	}
+	return counter.result(), nil
+}
+
+// GetIncidents reads the incidents that started within [from, to], and the
+// last one that started before from, which is the only one that may still
+// have been ongoing at from, since a service has one incident at a time.
+func (s *DynamoDBStorage) GetIncidents(service string, from, to time.Time) ([]internal.Incident, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(s.table),
+		KeyConditionExpression: aws.String("service = :service AND #timestamp BETWEEN :from AND :end"),
+		FilterExpression:       aws.String("attribute_not_exists(end_time) OR end_time >= :start"),
+		ExpressionAttributeNames: map[string]string{
+			"#timestamp": "timestamp",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":service": &types.AttributeValueMemberS{Value: incidentPartition(service)},
+			":start":   &types.AttributeValueMemberS{Value: formatIncidentTime(from)},
+			":from":    &types.AttributeValueMemberS{Value: formatTimestamp(from)},
+			":end":     &types.AttributeValueMemberS{Value: formatTimestamp(to)},
+		},
+	}
+	incidents, err := s.queryIncidents(input)
 	if err != nil {
-		return nil, fmt.Errorf("failed to query service %s: %v", service, err)
+		return nil, err
 	}
 
-	var statuses []internal.Status
-	for _, item := range result.Items {
-		var status internal.Status
-		if err := attributevalue.UnmarshalMap(item, &status); err != nil {
-			return nil, fmt.Errorf("failed to unmarshal status: %v", err)
+	result, err := s.client.Query(context.TODO(), &dynamodb.QueryInput{
+		TableName:              aws.String(s.table),
+		KeyConditionExpression: aws.String("service = :service AND #timestamp < :from"),
+		ExpressionAttributeNames: map[string]string{
+			"#timestamp": "timestamp",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":service": &types.AttributeValueMemberS{Value: incidentPartition(service)},
+			":from":    &types.AttributeValueMemberS{Value: formatTimestamp(from)},
+		},
+		ScanIndexForward: aws.Bool(false),
+		Limit:            aws.Int32(1),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to query incidents: %v", err)
+	}
+	if len(result.Items) == 0 {
+		return incidents, nil
+	}
+	previous, err := unmarshalIncident(result.Items[0])
+	if err != nil {
+		return nil, err
+	}
+	if previous.Ongoing() || !previous.EndTime.Before(from) {
+		incidents = append([]internal.Incident{previous}, incidents...)
+	}
+	return incidents, nil
+}
+
+// GetOpenIncident reads the copy of the ongoing incident that SaveIncident
+// keeps under openIncidentKey.
+func (s *DynamoDBStorage) GetOpenIncident(service string) (*internal.Incident, error) {
+	result, err := s.client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+		TableName: aws.String(s.table),
+		Key: map[string]types.AttributeValue{
+			"service":   &types.AttributeValueMemberS{Value: incidentPartition(service)},
+			"timestamp": &types.AttributeValueMemberS{Value: openIncidentKey},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get open incident: %v", err)
+	}
+	if result.Item == nil {
+		return nil, nil
+	}
+	incident, err := unmarshalIncident(result.Item)
+	if err != nil {
+		return nil, err
+	}
+	return &incident, nil
+}
+
+func (s *DynamoDBStorage) SaveIncident(incident internal.Incident) error {
+	item := map[string]interface{}{
+		"service":          incidentPartition(incident.Service),
+		"timestamp":        formatTimestamp(incident.StartTime),
+		"id":               incident.ID,
+		"incident_service": incident.Service,
+		"start_time":       formatIncidentTime(incident.StartTime),
+	}
+	if !incident.Ongoing() {
+		item["end_time"] = formatIncidentTime(incident.EndTime)
+	}
+	if incident.Cause != "" {
+		item["cause"] = incident.Cause
+	}
+
+	av, err := attributevalue.MarshalMap(item)
+	if err != nil {
+		return fmt.Errorf("failed to marshal incident: %v", err)
+	}
+	_, err = s.client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+		TableName: aws.String(s.table),
+		Item:      av,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save incident: %v", err)
+	}
+
+	// The ongoing incident is copied to openIncidentKey, so that it is found
+	// without reading the whole partition, and the copy is removed once the
+	// incident has ended.
+	key := &types.AttributeValueMemberS{Value: openIncidentKey}
+	if incident.Ongoing() {
+		av["timestamp"] = key
+		_, err = s.client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+			TableName: aws.String(s.table),
+			Item:      av,
+		})
+		if err != nil {
+			return fmt.Errorf("failed to save open incident: %v", err)
 		}
-		statuses = append(statuses, status)
+		return nil
+	}
+	_, err = s.client.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.table),
+		Key: map[string]types.AttributeValue{
+			"service":   av["service"],
+			"timestamp": key,
+		},
+		ConditionExpression: aws.String("#id = :id"),
+		ExpressionAttributeNames: map[string]string{
+			"#id": "id",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":id": av["id"],
+		},
+	})
+	var conditionFailed *types.ConditionalCheckFailedException
+	if err != nil && !errors.As(err, &conditionFailed) {
+		return fmt.Errorf("failed to clear open incident: %v", err)
 	}
+	return nil
+}
 
-	if len(statuses) == 0 {
+func (s *DynamoDBStorage) GetManualIncidents(from, to time.Time) ([]internal.ManualIncident, error) {
+	input := &dynamodb.QueryInput{
+		TableName:              aws.String(s.table),
+		KeyConditionExpression: aws.String("service = :service AND #timestamp < :end"),
+		FilterExpression:       aws.String("attribute_not_exists(end_time) OR end_time >= :start"),
+		ExpressionAttributeNames: map[string]string{
+			"#timestamp": "timestamp",
+		},
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":service": &types.AttributeValueMemberS{Value: manualIncidentPartition},
+			":start":   &types.AttributeValueMemberS{Value: formatIncidentTime(from)},
+			// Sort keys are "<start>#<id>"; "$" follows "#", so this keeps
+			// every incident that started within the last second of the range.
+			":end": &types.AttributeValueMemberS{Value: formatTimestamp(to) + "$"},
+		},
+	}
+	return s.queryManualIncidents(input)
+}
+
+// GetManualIncident looks up the sort key of the incident by its ID in
+// manualIncidentKeyPartition and then reads the incident itself.
+func (s *DynamoDBStorage) GetManualIncident(id string) (*internal.ManualIncident, error) {
+	result, err := s.client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+		TableName: aws.String(s.table),
+		Key: map[string]types.AttributeValue{
+			"service":   &types.AttributeValueMemberS{Value: manualIncidentKeyPartition},
+			"timestamp": &types.AttributeValueMemberS{Value: id},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get manual incident key: %v", err)
+	}
+	if result.Item == nil {
 		return nil, nil
 	}
+	var key dynamoManualIncidentKey
+	if err := attributevalue.UnmarshalMap(result.Item, &key); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal manual incident key: %v", err)
+	}
 
-	sort.Slice(statuses, func(i, j int) bool {
-		return statuses[i].Timestamp.Before(statuses[j].Timestamp)
+	result, err = s.client.GetItem(context.TODO(), &dynamodb.GetItemInput{
+		TableName: aws.String(s.table),
+		Key: map[string]types.AttributeValue{
+			"service":   &types.AttributeValueMemberS{Value: manualIncidentPartition},
+			"timestamp": &types.AttributeValueMemberS{Value: key.Key},
+		},
 	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get manual incident: %v", err)
+	}
+	if result.Item == nil {
+		return nil, nil
+	}
+	incident, err := unmarshalManualIncident(result.Item)
+	if err != nil {
+		return nil, err
+	}
+	return &incident, nil
+}
 
-	var incidents []internal.Incident
-	var currentIncident *internal.Incident
+func (s *DynamoDBStorage) SaveManualIncident(incident internal.ManualIncident) error {
+	stored := dynamoManualIncident{
+		Service:   manualIncidentPartition,
+		Timestamp: formatTimestamp(incident.StartTime) + "#" + incident.ID,
+		ID:        incident.ID,
+		Title:     incident.Title,
+		Services:  incident.Services,
+		StartTime: formatIncidentTime(incident.StartTime),
+	}
+	if !incident.Ongoing() {
+		stored.EndTime = formatIncidentTime(incident.EndTime)
+	}
+	for _, update := range incident.Updates {
+		stored.Updates = append(stored.Updates, dynamoIncidentUpdate{
+			Timestamp: formatIncidentTime(update.Timestamp),
+			Status:    string(update.Status),
+			Message:   update.Message,
+		})
+	}
 
-	for i, status := range statuses {
-		if currentIncident == nil {
-			currentIncident = &internal.Incident{
-				Service:   service,
-				StartTime: status.Timestamp,
-			}
+	av, err := attributevalue.MarshalMap(stored)
+	if err != nil {
+		return fmt.Errorf("failed to marshal manual incident: %v", err)
+	}
+	_, err = s.client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+		TableName: aws.String(s.table),
+		Item:      av,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save manual incident: %v", err)
+	}
+
+	key, err := attributevalue.MarshalMap(dynamoManualIncidentKey{
+		Service:   manualIncidentKeyPartition,
+		Timestamp: incident.ID,
+		Key:       stored.Timestamp,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal manual incident key: %v", err)
+	}
+	_, err = s.client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+		TableName: aws.String(s.table),
+		Item:      key,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save manual incident key: %v", err)
+	}
+	return nil
+}
+
+func (s *DynamoDBStorage) GetMaintenanceWindows() ([]internal.MaintenanceWindow, error) {
+	paginator := dynamodb.NewQueryPaginator(s.client, &dynamodb.QueryInput{
+		TableName:              aws.String(s.table),
+		KeyConditionExpression: aws.String("service = :service"),
+		ExpressionAttributeValues: map[string]types.AttributeValue{
+			":service": &types.AttributeValueMemberS{Value: maintenancePartition},
+		},
+	})
+
+	var windows []internal.MaintenanceWindow
+	for paginator.HasMorePages() {
+		result, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("failed to query maintenance windows: %v", err)
 		}
 
-		if i < len(statuses)-1 {
-			timeDiff := statuses[i+1].Timestamp.Sub(status.Timestamp)
-			if timeDiff.Minutes() >= 2 {
-				currentIncident.EndTime = status.Timestamp
-				incidents = append(incidents, *currentIncident)
-				currentIncident = nil
+		for _, item := range result.Items {
+			var stored dynamoMaintenanceWindow
+			if err := attributevalue.UnmarshalMap(item, &stored); err != nil {
+				return nil, fmt.Errorf("failed to unmarshal maintenance window: %v", err)
+			}
+			window := internal.MaintenanceWindow{
+				ID:       stored.ID,
+				Title:    stored.Title,
+				Services: stored.Services,
+				Groups:   stored.Groups,
+				Schedule: stored.Schedule,
+				Duration: time.Duration(stored.DurationMs) * time.Millisecond,
+			}
+			if stored.StartTime != "" {
+				if window.Start, err = time.Parse(time.RFC3339Nano, stored.StartTime); err != nil {
+					return nil, fmt.Errorf("failed to parse maintenance start: %v", err)
+				}
+				if window.End, err = time.Parse(time.RFC3339Nano, stored.EndTime); err != nil {
+					return nil, fmt.Errorf("failed to parse maintenance end: %v", err)
+				}
 			}
-		} else {
-			currentIncident.EndTime = status.Timestamp
-			incidents = append(incidents, *currentIncident)
+			windows = append(windows, window)
 		}
 	}
+	return windows, nil
+}
 
-	return incidents, nil
+func (s *DynamoDBStorage) SaveMaintenanceWindow(window internal.MaintenanceWindow) error {
+	stored := dynamoMaintenanceWindow{
+		Service:    maintenancePartition,
+		Timestamp:  window.ID,
+		ID:         window.ID,
+		Title:      window.Title,
+		Services:   window.Services,
+		Groups:     window.Groups,
+		Schedule:   window.Schedule,
+		DurationMs: window.Duration.Milliseconds(),
+	}
+	if !window.Recurring() {
+		stored.StartTime = formatIncidentTime(window.Start)
+		stored.EndTime = formatIncidentTime(window.End)
+	}
+
+	av, err := attributevalue.MarshalMap(stored)
+	if err != nil {
+		return fmt.Errorf("failed to marshal maintenance window: %v", err)
+	}
+	_, err = s.client.PutItem(context.TODO(), &dynamodb.PutItemInput{
+		TableName: aws.String(s.table),
+		Item:      av,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to save maintenance window: %v", err)
+	}
+	return nil
+}
+
+func (s *DynamoDBStorage) DeleteMaintenanceWindow(id string) error {
+	_, err := s.client.DeleteItem(context.TODO(), &dynamodb.DeleteItemInput{
+		TableName: aws.String(s.table),
+		Key: map[string]types.AttributeValue{
+			"service":   &types.AttributeValueMemberS{Value: maintenancePartition},
+			"timestamp": &types.AttributeValueMemberS{Value: id},
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("failed to delete maintenance window: %v", err)
+	}
+	return nil
 }
 
 // internals
 func (s *DynamoDBStorage) toDynamoDBData(status internal.Status) (map[string]types.AttributeValue, error) {
-	av, err := attributevalue.MarshalMap(map[string]interface{}{
+	item := map[string]interface{}{
 		"service":   status.Service,
-		"timestamp": status.Timestamp.Format(time.RFC3339),
+		"timestamp": formatTimestamp(status.Timestamp),
 		"status":    status.Status,
 		"latency":   status.Latency,
-	})
+	}
+	if status.Derived != "" {
+		item["derived"] = status.Derived
+	}
+	if status.Assertion != "" {
+		item["assertion"] = status.Assertion
+	}
+	if status.Message != "" {
+		item["message"] = status.Message
+	}
+	if len(status.Components) > 0 {
+		item["components"] = status.Components
+	}
+	if status.Certificate != nil {
+		item["certificate"] = status.Certificate
+	}
+
+	av, err := attributevalue.MarshalMap(item)
 	if err != nil {
 		return nil, err
 	}
@@ -197,3 +530,191 @@ func (s *DynamoDBStorage) batchWrite(writeRequests []types.WriteRequest) error {
 	})
 	return err
 }
+
+// dynamoIncident is the item layout of an incident.
+type dynamoIncident struct {
+	ID        string `dynamodbav:"id"`
+	Service   string `dynamodbav:"incident_service"`
+	StartTime string `dynamodbav:"start_time"`
+	EndTime   string `dynamodbav:"end_time"`
+	Cause     string `dynamodbav:"cause"`
+}
+
+// queryIncidents runs input over every page and returns the incidents, oldest first.
+func (s *DynamoDBStorage) queryIncidents(input *dynamodb.QueryInput) ([]internal.Incident, error) {
+	var incidents []internal.Incident
+	paginator := dynamodb.NewQueryPaginator(s.client, input)
+	for paginator.HasMorePages() {
+		result, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("failed to query incidents: %v", err)
+		}
+
+		for _, item := range result.Items {
+			incident, err := unmarshalIncident(item)
+			if err != nil {
+				return nil, err
+			}
+			incidents = append(incidents, incident)
+		}
+	}
+	sortIncidents(incidents)
+	return incidents, nil
+}
+
+func unmarshalIncident(item map[string]types.AttributeValue) (internal.Incident, error) {
+	var stored dynamoIncident
+	if err := attributevalue.UnmarshalMap(item, &stored); err != nil {
+		return internal.Incident{}, fmt.Errorf("failed to unmarshal incident: %v", err)
+	}
+	incident := internal.Incident{ID: stored.ID, Service: stored.Service, Cause: stored.Cause}
+	var err error
+	if incident.StartTime, err = time.Parse(time.RFC3339Nano, stored.StartTime); err != nil {
+		return internal.Incident{}, fmt.Errorf("failed to parse incident start: %v", err)
+	}
+	if stored.EndTime != "" {
+		if incident.EndTime, err = time.Parse(time.RFC3339Nano, stored.EndTime); err != nil {
+			return internal.Incident{}, fmt.Errorf("failed to parse incident end: %v", err)
+		}
+	}
+	return incident, nil
+}
+
+// dynamoManualIncident is the item layout of a manual incident.
+type dynamoManualIncident struct {
+	Service   string                 `dynamodbav:"service"`
+	Timestamp string                 `dynamodbav:"timestamp"`
+	ID        string                 `dynamodbav:"id"`
+	Title     string                 `dynamodbav:"title"`
+	Services  []string               `dynamodbav:"services"`
+	StartTime string                 `dynamodbav:"start_time"`
+	EndTime   string                 `dynamodbav:"end_time,omitempty"`
+	Updates   []dynamoIncidentUpdate `dynamodbav:"updates"`
+}
+
+// dynamoManualIncidentKey maps the ID of a manual incident to its sort key.
+type dynamoManualIncidentKey struct {
+	Service   string `dynamodbav:"service"`
+	Timestamp string `dynamodbav:"timestamp"`
+	Key       string `dynamodbav:"key"`
+}
+
+type dynamoIncidentUpdate struct {
+	Timestamp string `dynamodbav:"timestamp"`
+	Status    string `dynamodbav:"status"`
+	Message   string `dynamodbav:"message"`
+}
+
+// queryManualIncidents runs input over every page and returns the manual
+// incidents, oldest first.
+func (s *DynamoDBStorage) queryManualIncidents(input *dynamodb.QueryInput) ([]internal.ManualIncident, error) {
+	var incidents []internal.ManualIncident
+	paginator := dynamodb.NewQueryPaginator(s.client, input)
+	for paginator.HasMorePages() {
+		result, err := paginator.NextPage(context.TODO())
+		if err != nil {
+			return nil, fmt.Errorf("failed to query manual incidents: %v", err)
+		}
+
+		for _, item := range result.Items {
+			incident, err := unmarshalManualIncident(item)
+			if err != nil {
+				return nil, err
+			}
+			incidents = append(incidents, incident)
+		}
+	}
+	sortManualIncidents(incidents)
+	return incidents, nil
+}
+
+func unmarshalManualIncident(item map[string]types.AttributeValue) (internal.ManualIncident, error) {
+	var stored dynamoManualIncident
+	if err := attributevalue.UnmarshalMap(item, &stored); err != nil {
+		return internal.ManualIncident{}, fmt.Errorf("failed to unmarshal manual incident: %v", err)
+	}
+	incident := internal.ManualIncident{ID: stored.ID, Title: stored.Title, Services: stored.Services}
+	var err error
+	if incident.StartTime, err = time.Parse(time.RFC3339Nano, stored.StartTime); err != nil {
+		return internal.ManualIncident{}, fmt.Errorf("failed to parse incident start: %v", err)
+	}
+	if stored.EndTime != "" {
+		if incident.EndTime, err = time.Parse(time.RFC3339Nano, stored.EndTime); err != nil {
+			return internal.ManualIncident{}, fmt.Errorf("failed to parse incident end: %v", err)
+		}
+	}
+	for _, update := range stored.Updates {
+		timestamp, err := time.Parse(time.RFC3339Nano, update.Timestamp)
+		if err != nil {
+			return internal.ManualIncident{}, fmt.Errorf("failed to parse incident update: %v", err)
+		}
+		incident.Updates = append(incident.Updates, internal.IncidentUpdate{
+			Timestamp: timestamp,
+			Status:    internal.IncidentStatus(update.Status),
+			Message:   update.Message,
+		})
+	}
+	return incident, nil
+}
+
+// dynamoMaintenanceWindow is the item layout of a maintenance window. The sort
+// key is the ID, since windows are always read together.
+type dynamoMaintenanceWindow struct {
+	Service    string   `dynamodbav:"service"`
+	Timestamp  string   `dynamodbav:"timestamp"`
+	ID         string   `dynamodbav:"id"`
+	Title      string   `dynamodbav:"title"`
+	Services   []string `dynamodbav:"services"`
+	Groups     []string `dynamodbav:"groups"`
+	StartTime  string   `dynamodbav:"start_time,omitempty"`
+	EndTime    string   `dynamodbav:"end_time,omitempty"`
+	Schedule   string   `dynamodbav:"schedule,omitempty"`
+	DurationMs int64    `dynamodbav:"duration_ms,omitempty"`
+}
+
+func unmarshalStatuses(items []map[string]types.AttributeValue) ([]internal.Status, error) {
+	statuses := make([]internal.Status, 0, len(items))
+	for _, item := range items {
+		var status internal.Status
+		if err := attributevalue.UnmarshalMap(item, &status); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal status: %v", err)
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, nil
+}
+
+// formatTimestamp formats the sort key as earlier versions did: RFC 3339 in
+// the local time zone of the process, e.g. "2024-05-01T19:00:00+09:00". Keys
+// only sort chronologically while every key has the same offset, so tables
+// must keep being written and read with the same TZ.
+func formatTimestamp(t time.Time) string {
+	return t.In(time.Local).Format(time.RFC3339)
+}
+
+// manualIncidentPartition holds the manual incidents of every service.
+const manualIncidentPartition = "manual#incidents"
+
+// manualIncidentKeyPartition maps the ID of every manual incident to its sort
+// key in manualIncidentPartition.
+const manualIncidentKeyPartition = "manual#incident-ids"
+
+// openIncidentKey is the sort key of the copy of the ongoing incident in the
+// incident partition of a service. It sorts after every timestamp, so that
+// range queries leave it out.
+const openIncidentKey = "open"
+
+// maintenancePartition holds the maintenance windows created through the API.
+const maintenancePartition = "maintenance#windows"
+
+// incidentPartition keeps incidents in the statuses table without mixing them
+// into the history of the service.
+func incidentPartition(service string) string {
+	return "incident#" + service
+}
+
+// formatIncidentTime keeps full precision, unlike the sort key, and sorts
+// lexically as long as it is in UTC.
+func formatIncidentTime(t time.Time) string {
+	return t.UTC().Format("2006-01-02T15:04:05.000000000Z07:00")
+}
@@ -0,0 +1,28 @@
+package storage
+
+import (
+	"int-status/internal"
+	"sort"
+	"time"
+)
+
+// overlaps reports whether an incident from start to end overlaps [from, to].
+// A zero end is an ongoing incident.
+func overlaps(start, end, from, to time.Time) bool {
+	if start.After(to) {
+		return false
+	}
+	return end.IsZero() || !end.Before(from)
+}
+
+func sortIncidents(incidents []internal.Incident) {
+	sort.Slice(incidents, func(i, j int) bool {
+		return incidents[i].StartTime.Before(incidents[j].StartTime)
+	})
+}
+
+func sortManualIncidents(incidents []internal.ManualIncident) {
+	sort.Slice(incidents, func(i, j int) bool {
+		return incidents[i].StartTime.Before(incidents[j].StartTime)
+	})
+}
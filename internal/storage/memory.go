@@ -0,0 +1,399 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"int-status/internal"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+)
+
+func init() {
+	Register("memory", func(conf internal.StorageConf) (Storage, error) {
+		return NewMemoryStorage(conf.Memory.Capacity, conf.Memory.SnapshotPath)
+	})
+}
+
+// ringBuffer keeps the most recent statuses of one service.
+type ringBuffer struct {
+	items []internal.Status
+	next  int
+	full  bool
+}
+
+func newRingBuffer(capacity int) *ringBuffer {
+	return &ringBuffer{items: make([]internal.Status, capacity)}
+}
+
+func (r *ringBuffer) push(status internal.Status) {
+	r.items[r.next] = status
+	r.next = (r.next + 1) % len(r.items)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// all returns the buffered statuses, oldest first.
+func (r *ringBuffer) all() []internal.Status {
+	if !r.full {
+		return append([]internal.Status(nil), r.items[:r.next]...)
+	}
+	return append(append([]internal.Status(nil), r.items[r.next:]...), r.items[:r.next]...)
+}
+
+// MemoryStorage keeps the last N statuses and incidents per service, the last
+// N manual incidents and every maintenance window in memory. It needs no
+// external database and is meant for demos, ephemeral deployments and tests.
+type MemoryStorage struct {
+	mu           sync.RWMutex
+	capacity     int
+	buffers      map[string]*ringBuffer
+	incidents    map[string][]internal.Incident
+	manual       []internal.ManualIncident
+	windows      []internal.MaintenanceWindow
+	snapshotPath string
+}
+
+// memorySnapshot is the file format of MemoryStorage snapshots.
+type memorySnapshot struct {
+	Statuses           map[string][]internal.Status   `json:"statuses"`
+	Incidents          map[string][]internal.Incident `json:"incidents"`
+	ManualIncidents    []internal.ManualIncident      `json:"manual_incidents,omitempty"`
+	MaintenanceWindows []internal.MaintenanceWindow   `json:"maintenance_windows,omitempty"`
+}
+
+// NewMemoryStorage creates a MemoryStorage holding capacity statuses per
+// service. When snapshotPath is set, a previous snapshot is restored.
+func NewMemoryStorage(capacity int, snapshotPath string) (*MemoryStorage, error) {
+	if capacity <= 0 {
+		return nil, fmt.Errorf("memory storage capacity must be positive, got %d", capacity)
+	}
+
+	s := &MemoryStorage{
+		capacity:     capacity,
+		buffers:      make(map[string]*ringBuffer),
+		incidents:    make(map[string][]internal.Incident),
+		snapshotPath: snapshotPath,
+	}
+	if snapshotPath != "" {
+		if err := s.restore(); err != nil {
+			return nil, err
+		}
+	}
+	return s, nil
+}
+
+// Close writes a snapshot if a snapshot path is configured.
+func (s *MemoryStorage) Close() error {
+	if s.snapshotPath == "" {
+		return nil
+	}
+	return s.snapshot()
+}
+
+func (s *MemoryStorage) UpdateHistory(statuses []internal.Status) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, status := range statuses {
+		s.push(status)
+	}
+	return nil
+}
+
+func (s *MemoryStorage) GetHistory(service string, query HistoryQuery) (HistoryPage, error) {
+	to := query.To
+	if query.Cursor != "" {
+		before, err := decodeTimeCursor(query.Cursor)
+		if err != nil {
+			return HistoryPage{}, err
+		}
+		to = before.Add(-time.Nanosecond)
+	}
+
+	statuses := s.between(service, query.From, to)
+
+	var page HistoryPage
+	if limit := pageLimit(query); len(statuses) > limit {
+		statuses = statuses[len(statuses)-limit:]
+		page.NextCursor = encodeTimeCursor(statuses[0].Timestamp)
+	}
+	page.Statuses = statuses
+	return page, nil
+}
+
+func (s *MemoryStorage) GetUptime(service string, froms []time.Time, to time.Time, maxGap time.Duration) ([]internal.Uptime, error) {
+	counter := newUptimeCounter(froms, to, maxGap)
+	for _, status := range s.between(service, counter.queryStart(), to) {
+		counter.add(status)
+	}
+	return counter.result(), nil
+}
+
+func (s *MemoryStorage) GetIncidents(service string, from, to time.Time) ([]internal.Incident, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var incidents []internal.Incident
+	for _, incident := range s.incidents[service] {
+		if overlaps(incident.StartTime, incident.EndTime, from, to) {
+			incidents = append(incidents, incident)
+		}
+	}
+	return incidents, nil
+}
+
+func (s *MemoryStorage) GetOpenIncident(service string) (*internal.Incident, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	incidents := s.incidents[service]
+	for i := len(incidents) - 1; i >= 0; i-- {
+		if incidents[i].Ongoing() {
+			incident := incidents[i]
+			return &incident, nil
+		}
+	}
+	return nil, nil
+}
+
+// SaveIncident keeps at most capacity incidents per service, dropping the oldest.
+func (s *MemoryStorage) SaveIncident(incident internal.Incident) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.saveIncident(incident)
+	return nil
+}
+
+func (s *MemoryStorage) GetManualIncidents(from, to time.Time) ([]internal.ManualIncident, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var incidents []internal.ManualIncident
+	for _, incident := range s.manual {
+		if overlaps(incident.StartTime, incident.EndTime, from, to) {
+			incidents = append(incidents, copyManualIncident(incident))
+		}
+	}
+	return incidents, nil
+}
+
+func (s *MemoryStorage) GetManualIncident(id string) (*internal.ManualIncident, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, incident := range s.manual {
+		if incident.ID == id {
+			copied := copyManualIncident(incident)
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+// SaveManualIncident keeps at most capacity manual incidents, dropping the oldest.
+func (s *MemoryStorage) SaveManualIncident(incident internal.ManualIncident) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.saveManualIncident(copyManualIncident(incident))
+	return nil
+}
+
+func (s *MemoryStorage) GetMaintenanceWindows() ([]internal.MaintenanceWindow, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	windows := make([]internal.MaintenanceWindow, len(s.windows))
+	for i, window := range s.windows {
+		windows[i] = copyMaintenanceWindow(window)
+	}
+	return windows, nil
+}
+
+func (s *MemoryStorage) SaveMaintenanceWindow(window internal.MaintenanceWindow) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.saveMaintenanceWindow(copyMaintenanceWindow(window))
+	return nil
+}
+
+func (s *MemoryStorage) DeleteMaintenanceWindow(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.windows {
+		if s.windows[i].ID == id {
+			s.windows = append(s.windows[:i], s.windows[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+// internals
+func (s *MemoryStorage) push(status internal.Status) {
+	buffer, ok := s.buffers[status.Service]
+	if !ok {
+		buffer = newRingBuffer(s.capacity)
+		s.buffers[status.Service] = buffer
+	}
+	buffer.push(status)
+}
+
+func (s *MemoryStorage) saveIncident(incident internal.Incident) {
+	incidents := s.incidents[incident.Service]
+	for i := range incidents {
+		if incidents[i].ID == incident.ID {
+			incidents[i] = incident
+			return
+		}
+	}
+
+	incidents = append(incidents, incident)
+	sortIncidents(incidents)
+	if len(incidents) > s.capacity {
+		incidents = incidents[len(incidents)-s.capacity:]
+	}
+	s.incidents[incident.Service] = incidents
+}
+
+func (s *MemoryStorage) saveManualIncident(incident internal.ManualIncident) {
+	for i := range s.manual {
+		if s.manual[i].ID == incident.ID {
+			s.manual[i] = incident
+			return
+		}
+	}
+
+	s.manual = append(s.manual, incident)
+	sortManualIncidents(s.manual)
+	if len(s.manual) > s.capacity {
+		s.manual = s.manual[len(s.manual)-s.capacity:]
+	}
+}
+
+func (s *MemoryStorage) saveMaintenanceWindow(window internal.MaintenanceWindow) {
+	for i := range s.windows {
+		if s.windows[i].ID == window.ID {
+			s.windows[i] = window
+			return
+		}
+	}
+	s.windows = append(s.windows, window)
+}
+
+// copyMaintenanceWindow copies the slices of window, so that callers cannot
+// modify the stored window.
+func copyMaintenanceWindow(window internal.MaintenanceWindow) internal.MaintenanceWindow {
+	window.Services = append([]string(nil), window.Services...)
+	window.Groups = append([]string(nil), window.Groups...)
+	return window
+}
+
+// copyManualIncident copies the slices of incident, so that callers cannot
+// modify the stored incident.
+func copyManualIncident(incident internal.ManualIncident) internal.ManualIncident {
+	incident.Services = append([]string(nil), incident.Services...)
+	incident.Updates = append([]internal.IncidentUpdate(nil), incident.Updates...)
+	return incident
+}
+
+// between returns the statuses of a service within [from, to], oldest first.
+func (s *MemoryStorage) between(service string, from, to time.Time) []internal.Status {
+	s.mu.RLock()
+	buffer, ok := s.buffers[service]
+	var all []internal.Status
+	if ok {
+		all = buffer.all()
+	}
+	s.mu.RUnlock()
+
+	var statuses []internal.Status
+	for _, status := range all {
+		if status.Timestamp.Before(from) || status.Timestamp.After(to) {
+			continue
+		}
+		statuses = append(statuses, status)
+	}
+	sort.SliceStable(statuses, func(i, j int) bool {
+		return statuses[i].Timestamp.Before(statuses[j].Timestamp)
+	})
+	return statuses
+}
+
+func (s *MemoryStorage) snapshot() error {
+	s.mu.RLock()
+	data := memorySnapshot{
+		Statuses:  make(map[string][]internal.Status, len(s.buffers)),
+		Incidents: make(map[string][]internal.Incident, len(s.incidents)),
+	}
+	for service, buffer := range s.buffers {
+		data.Statuses[service] = buffer.all()
+	}
+	for service, incidents := range s.incidents {
+		data.Incidents[service] = append([]internal.Incident(nil), incidents...)
+	}
+	data.ManualIncidents = append([]internal.ManualIncident(nil), s.manual...)
+	data.MaintenanceWindows = append([]internal.MaintenanceWindow(nil), s.windows...)
+	s.mu.RUnlock()
+
+	encoded, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to encode memory snapshot: %v", err)
+	}
+
+	if err := os.MkdirAll(filepath.Dir(s.snapshotPath), 0o755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %v", err)
+	}
+	// Write to a temporary file first so that a crash never leaves a torn snapshot.
+	tmp := s.snapshotPath + ".tmp"
+	if err := os.WriteFile(tmp, encoded, 0o644); err != nil {
+		return fmt.Errorf("failed to write memory snapshot: %v", err)
+	}
+	return os.Rename(tmp, s.snapshotPath)
+}
+
+func (s *MemoryStorage) restore() error {
+	encoded, err := os.ReadFile(s.snapshotPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to read memory snapshot: %v", err)
+	}
+
+	var data memorySnapshot
+	if err := json.Unmarshal(encoded, &data); err != nil {
+		return fmt.Errorf("failed to decode memory snapshot: %v", err)
+	}
+	if data.Statuses == nil && data.Incidents == nil {
+		// Older snapshots hold only the statuses, keyed by service.
+		if err := json.Unmarshal(encoded, &data.Statuses); err != nil {
+			return fmt.Errorf("failed to decode memory snapshot: %v", err)
+		}
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, statuses := range data.Statuses {
+		for _, status := range statuses {
+			s.push(status)
+		}
+	}
+	for _, incidents := range data.Incidents {
+		for _, incident := range incidents {
+			s.saveIncident(incident)
+		}
+	}
+	for _, incident := range data.ManualIncidents {
+		s.saveManualIncident(incident)
+	}
+	for _, window := range data.MaintenanceWindows {
+		s.saveMaintenanceWindow(window)
+	}
+	return nil
+}
@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"int-status/internal"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func testStatuses(service string, start time.Time, n int) []internal.Status {
+	statuses := make([]internal.Status, n)
+	for i := range statuses {
+		statuses[i] = internal.Status{
+			Service:   service,
+			Status:    internal.StateUp,
+			Derived:   internal.StateUp,
+			Latency:   int64(i),
+			Timestamp: start.Add(time.Duration(i) * time.Minute),
+		}
+	}
+	return statuses
+}
+
+func TestMemoryStorageEvictsOldest(t *testing.T) {
+	s, err := NewMemoryStorage(3, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	statuses := testStatuses("web", start, 5)
+	if err := s.UpdateHistory(statuses[:2]); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.UpdateHistory(append(statuses[2:], testStatuses("api", start, 1)...)); err != nil {
+		t.Fatal(err)
+	}
+
+	all, err := GetAllHistory(s, "web", start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(all, statuses[2:]) {
+		t.Errorf("web history = %v, want the 3 newest statuses", all)
+	}
+	// Every service has a buffer of its own.
+	if api, _ := GetAllHistory(s, "api", start, start.Add(time.Hour)); len(api) != 1 {
+		t.Errorf("api history has %d statuses, want 1", len(api))
+	}
+
+	for i := 0; i < 5; i++ {
+		incident := internal.Incident{ID: string(rune('a' + i)), Service: "web", StartTime: start.Add(time.Duration(i) * time.Minute)}
+		if err := s.SaveIncident(incident); err != nil {
+			t.Fatal(err)
+		}
+	}
+	incidents, err := s.GetIncidents("web", start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(incidents) != 3 || incidents[0].ID != "c" || incidents[2].ID != "e" {
+		t.Errorf("incidents = %v, want the 3 newest", incidents)
+	}
+}
+
+func TestNewMemoryStorageRejectsInvalidCapacity(t *testing.T) {
+	if _, err := NewMemoryStorage(0, ""); err == nil {
+		t.Error("a capacity of 0 was accepted")
+	}
+}
+
+func TestMemoryStorageSnapshotRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data", "snapshot.json")
+	s, err := NewMemoryStorage(10, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	statuses := testStatuses("web", start, 12)
+	incident := internal.Incident{ID: "3f1c9a0e5b7d2c48", Service: "web", StartTime: start, Cause: "got status 503"}
+	manual := internal.ManualIncident{
+		ID:        "m1",
+		Title:     "Delayed payouts",
+		Services:  []string{"web"},
+		StartTime: start,
+		Updates:   []internal.IncidentUpdate{{Timestamp: start, Status: internal.IncidentInvestigating, Message: "Looking into it"}},
+	}
+	window := internal.MaintenanceWindow{ID: "w1", Title: "Upgrade", Services: []string{"web"}, Start: start, End: start.Add(time.Hour)}
+	if err := s.UpdateHistory(statuses); err != nil {
+		t.Fatal(err)
+	}
+	for _, save := range []error{s.SaveIncident(incident), s.SaveManualIncident(manual), s.SaveMaintenanceWindow(window)} {
+		if save != nil {
+			t.Fatal(save)
+		}
+	}
+	if err := s.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	restored, err := NewMemoryStorage(10, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	history, err := GetAllHistory(restored, "web", start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 10 || !history[0].Timestamp.Equal(statuses[2].Timestamp) || history[9].Latency != 11 {
+		t.Errorf("restored history = %v, want the 10 newest statuses", history)
+	}
+	open, err := restored.GetOpenIncident("web")
+	if err != nil || open == nil || open.ID != incident.ID || open.Cause != incident.Cause {
+		t.Errorf("restored open incident = %v (%v), want %v", open, err, incident)
+	}
+	restoredManual, err := restored.GetManualIncident("m1")
+	if err != nil || restoredManual == nil || restoredManual.Title != manual.Title || len(restoredManual.Updates) != 1 {
+		t.Errorf("restored manual incident = %v (%v), want %v", restoredManual, err, manual)
+	}
+	windows, err := restored.GetMaintenanceWindows()
+	if err != nil || len(windows) != 1 || windows[0].ID != "w1" || !windows[0].End.Equal(window.End) {
+		t.Errorf("restored maintenance windows = %v (%v), want %v", windows, err, window)
+	}
+
+	// A smaller capacity keeps the newest statuses of the snapshot.
+	smaller, err := NewMemoryStorage(4, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if history, _ := GetAllHistory(smaller, "web", start, start.Add(time.Hour)); len(history) != 4 || history[3].Latency != 11 {
+		t.Errorf("history restored into capacity 4 = %v, want the 4 newest statuses", history)
+	}
+}
+
+func TestMemoryStorageRestoresStatusOnlySnapshot(t *testing.T) {
+	// Snapshots written before incidents were kept hold only the statuses.
+	path := filepath.Join(t.TempDir(), "snapshot.json")
+	legacy := `{"web": [{"Service": "web", "Status": "UP", "Timestamp": "2024-05-01T10:00:00Z"}]}`
+	if err := os.WriteFile(path, []byte(legacy), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s, err := NewMemoryStorage(10, path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	history, err := GetAllHistory(s, "web", start, start.Add(time.Minute))
+	if err != nil || len(history) != 1 || history[0].Status != internal.StateUp {
+		t.Errorf("history = %v (%v), want the legacy status", history, err)
+	}
+}
+
+func TestMemoryStorageUptime(t *testing.T) {
+	s, err := NewMemoryStorage(100, "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	start := time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC)
+	statuses := []internal.Status{
+		{Service: "web", Status: internal.StateUp, Timestamp: start},
+		{Service: "web", Status: internal.StateDown, Timestamp: start.Add(10 * time.Minute)},
+		// A gap longer than maxGap, e.g. while TinyPing was stopped.
+		{Service: "web", Status: internal.StateUp, Timestamp: start.Add(time.Hour)},
+	}
+	if err := s.UpdateHistory(statuses); err != nil {
+		t.Fatal(err)
+	}
+
+	froms := []time.Time{start.Add(-time.Hour), start.Add(5 * time.Minute), start.Add(65 * time.Minute)}
+	uptimes, err := s.GetUptime("web", froms, start.Add(70*time.Minute), 20*time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []internal.Uptime{
+		{Up: 20 * time.Minute, Down: 20 * time.Minute},
+		{Up: 15 * time.Minute, Down: 20 * time.Minute},
+		{Up: 5 * time.Minute},
+	}
+	if !reflect.DeepEqual(uptimes, want) {
+		t.Errorf("GetUptime = %+v, want %+v", uptimes, want)
+	}
+}
@@ -0,0 +1,43 @@
+package storage
+
+import (
+	"encoding/base64"
+	"fmt"
+	"int-status/internal"
+	"strconv"
+	"time"
+)
+
+// pageLimit returns the effective page size of a query.
+func pageLimit(query HistoryQuery) int {
+	if query.Limit <= 0 {
+		return DefaultPageSize
+	}
+	return query.Limit
+}
+
+// encodeTimeCursor turns the timestamp of the oldest status of a page into a
+// cursor. The next page holds statuses strictly older than it.
+func encodeTimeCursor(t time.Time) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(t.UnixNano(), 10)))
+}
+
+func decodeTimeCursor(cursor string) (time.Time, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	nanos, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+	return time.Unix(0, nanos), nil
+}
+
+// reverse reverses statuses in place, turning a newest-first page into an
+// oldest-first one.
+func reverse(statuses []internal.Status) {
+	for i, j := 0, len(statuses)-1; i < j; i, j = i+1, j-1 {
+		statuses[i], statuses[j] = statuses[j], statuses[i]
+	}
+}
@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"fmt"
+	"int-status/internal"
+	"sort"
+	"sync"
+)
+
+// Factory creates a Storage backend from the storage configuration.
+type Factory func(conf internal.StorageConf) (Storage, error)
+
+var (
+	backendsMu sync.RWMutex
+	backends   = make(map[string]Factory)
+)
+
+// Register makes a storage backend available under name. It is meant to be
+// called from the init function of the file implementing the backend.
+func Register(name string, factory Factory) {
+	backendsMu.Lock()
+	defer backendsMu.Unlock()
+
+	if _, exists := backends[name]; exists {
+		panic(fmt.Sprintf("storage: backend %q registered twice", name))
+	}
+	backends[name] = factory
+}
+
+// Open creates the backend selected by conf.Backend.
+func Open(conf internal.StorageConf) (Storage, error) {
+	backendsMu.RLock()
+	factory, ok := backends[conf.Backend]
+	backendsMu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("unknown storage backend %q (available: %v)", conf.Backend, Backends())
+	}
+	return factory(conf)
+}
+
+// Backends returns the names of all registered backends.
+func Backends() []string {
+	backendsMu.RLock()
+	defer backendsMu.RUnlock()
+
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,539 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"int-status/internal"
+	"os"
+	"path/filepath"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+func init() {
+	Register("sqlite", func(conf internal.StorageConf) (Storage, error) {
+		return NewSQLiteStorage(conf.SQLite.Path)
+	})
+}
+
+// sqliteMigrations are applied in order. Never edit an entry once released;
+// append a new one instead.
+var sqliteMigrations = []string{
+	`CREATE TABLE statuses (
+		service     TEXT    NOT NULL,
+		timestamp   INTEGER NOT NULL,
+		status      TEXT    NOT NULL,
+		latency     INTEGER NOT NULL,
+		assertion   TEXT    NOT NULL DEFAULT '',
+		message     TEXT    NOT NULL DEFAULT '',
+		components  TEXT,
+		certificate TEXT,
+		PRIMARY KEY (service, timestamp)
+	)`,
+	`ALTER TABLE statuses ADD COLUMN derived TEXT NOT NULL DEFAULT ''`,
+	`CREATE TABLE incidents (
+		id         TEXT    PRIMARY KEY,
+		service    TEXT    NOT NULL,
+		start_time INTEGER NOT NULL,
+		end_time   INTEGER,
+		cause      TEXT    NOT NULL DEFAULT ''
+	)`,
+	`CREATE INDEX incidents_service_start ON incidents (service, start_time)`,
+	`CREATE TABLE manual_incidents (
+		id         TEXT    PRIMARY KEY,
+		title      TEXT    NOT NULL,
+		services   TEXT    NOT NULL,
+		start_time INTEGER NOT NULL,
+		end_time   INTEGER
+	)`,
+	`CREATE TABLE incident_updates (
+		incident_id TEXT    NOT NULL,
+		timestamp   INTEGER NOT NULL,
+		status      TEXT    NOT NULL,
+		message     TEXT    NOT NULL DEFAULT ''
+	)`,
+	`CREATE INDEX incident_updates_incident ON incident_updates (incident_id, timestamp)`,
+	`CREATE TABLE maintenance_windows (
+		id             TEXT    PRIMARY KEY,
+		title          TEXT    NOT NULL DEFAULT '',
+		services       TEXT    NOT NULL,
+		service_groups TEXT    NOT NULL,
+		start_time     INTEGER,
+		end_time       INTEGER,
+		schedule       TEXT    NOT NULL DEFAULT '',
+		duration       INTEGER NOT NULL DEFAULT 0
+	)`,
+}
+
+// sqliteColumns are the columns scanned by query, in order.
+const sqliteColumns = `service, timestamp, status, derived, latency, assertion, message, components, certificate`
+
+// SQLiteStorage stores statuses in an embedded SQLite database file.
+type SQLiteStorage struct {
+	db *sql.DB
+}
+
+// NewSQLiteStorage opens (or creates) the database at path and migrates it
+// to the latest schema.
+func NewSQLiteStorage(path string) (*SQLiteStorage, error) {
+	if dir := filepath.Dir(path); dir != "" {
+		if err := os.MkdirAll(dir, 0o755); err != nil {
+			return nil, fmt.Errorf("failed to create data directory: %v", err)
+		}
+	}
+
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, fmt.Errorf("failed to open SQLite database: %v", err)
+	}
+	// SQLite allows a single writer; serializing connections avoids SQLITE_BUSY.
+	db.SetMaxOpenConns(1)
+
+	s := &SQLiteStorage{db: db}
+	if err := s.migrate(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+// Close closes the underlying database.
+func (s *SQLiteStorage) Close() error {
+	return s.db.Close()
+}
+
+func (s *SQLiteStorage) UpdateHistory(statuses []internal.Status) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	stmt, err := tx.Prepare(`INSERT OR REPLACE INTO statuses
+		(service, timestamp, status, derived, latency, assertion, message, components, certificate)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare insert: %v", err)
+	}
+	defer stmt.Close()
+
+	for _, status := range statuses {
+		components, err := marshalNullable(status.Components, len(status.Components) > 0)
+		if err != nil {
+			return fmt.Errorf("failed to marshal status data: %v", err)
+		}
+		certificate, err := marshalNullable(status.Certificate, status.Certificate != nil)
+		if err != nil {
+			return fmt.Errorf("failed to marshal status data: %v", err)
+		}
+
+		_, err = stmt.Exec(status.Service, status.Timestamp.UnixMilli(), string(status.Status),
+			string(status.Derived), status.Latency, status.Assertion, status.Message, components, certificate)
+		if err != nil {
+			return fmt.Errorf("failed to insert status: %v", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (s *SQLiteStorage) GetHistory(service string, query HistoryQuery) (HistoryPage, error) {
+	to := query.To.UnixMilli()
+	if query.Cursor != "" {
+		before, err := decodeTimeCursor(query.Cursor)
+		if err != nil {
+			return HistoryPage{}, err
+		}
+		to = before.UnixMilli() - 1
+	}
+	limit := pageLimit(query)
+
+	// One extra row tells whether another page follows.
+	statuses, err := s.query(`SELECT `+sqliteColumns+`
+		FROM statuses
+		WHERE service = ? AND timestamp BETWEEN ? AND ?
+		ORDER BY timestamp DESC
+		LIMIT ?`, service, query.From.UnixMilli(), to, limit+1)
+	if err != nil {
+		return HistoryPage{}, err
+	}
+
+	var page HistoryPage
+	if len(statuses) > limit {
+		statuses = statuses[:limit]
+		page.NextCursor = encodeTimeCursor(statuses[limit-1].Timestamp)
+	}
+	reverse(statuses)
+	page.Statuses = statuses
+	return page, nil
+}
+
+// GetUptime reads only the columns it needs, since a range may span months
+// of statuses.
+func (s *SQLiteStorage) GetUptime(service string, froms []time.Time, to time.Time, maxGap time.Duration) ([]internal.Uptime, error) {
+	counter := newUptimeCounter(froms, to, maxGap)
+	rows, err := s.db.Query(`SELECT timestamp, status, derived
+		FROM statuses
+		WHERE service = ? AND timestamp BETWEEN ? AND ?
+		ORDER BY timestamp`, service, counter.queryStart().UnixMilli(), to.UnixMilli())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query SQLite: %v", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var status internal.Status
+		var timestamp int64
+		if err := rows.Scan(&timestamp, &status.Status, &status.Derived); err != nil {
+			return nil, err
+		}
+		status.Timestamp = time.UnixMilli(timestamp)
+		counter.add(status)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return counter.result(), nil
+}
+
+func (s *SQLiteStorage) GetIncidents(service string, from, to time.Time) ([]internal.Incident, error) {
+	incidents, err := s.queryIncidents(`SELECT id, service, start_time, end_time, cause
+		FROM incidents
+		WHERE service = ? AND start_time <= ? AND (end_time IS NULL OR end_time >= ?)
+		ORDER BY start_time`, service, to.UnixMilli(), from.UnixMilli())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query incidents of %s: %v", service, err)
+	}
+	return incidents, nil
+}
+
+func (s *SQLiteStorage) GetOpenIncident(service string) (*internal.Incident, error) {
+	incidents, err := s.queryIncidents(`SELECT id, service, start_time, end_time, cause
+		FROM incidents
+		WHERE service = ? AND end_time IS NULL
+		ORDER BY start_time DESC
+		LIMIT 1`, service)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query open incident of %s: %v", service, err)
+	}
+	if len(incidents) == 0 {
+		return nil, nil
+	}
+	return &incidents[0], nil
+}
+
+func (s *SQLiteStorage) SaveIncident(incident internal.Incident) error {
+	var endTime sql.NullInt64
+	if !incident.Ongoing() {
+		endTime = sql.NullInt64{Int64: incident.EndTime.UnixMilli(), Valid: true}
+	}
+	_, err := s.db.Exec(`INSERT OR REPLACE INTO incidents (id, service, start_time, end_time, cause)
+		VALUES (?, ?, ?, ?, ?)`,
+		incident.ID, incident.Service, incident.StartTime.UnixMilli(), endTime, incident.Cause)
+	if err != nil {
+		return fmt.Errorf("failed to save incident: %v", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStorage) GetManualIncidents(from, to time.Time) ([]internal.ManualIncident, error) {
+	incidents, err := s.queryManualIncidents(`SELECT id, title, services, start_time, end_time
+		FROM manual_incidents
+		WHERE start_time <= ? AND (end_time IS NULL OR end_time >= ?)
+		ORDER BY start_time`, to.UnixMilli(), from.UnixMilli())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query manual incidents: %v", err)
+	}
+	return incidents, nil
+}
+
+func (s *SQLiteStorage) GetManualIncident(id string) (*internal.ManualIncident, error) {
+	incidents, err := s.queryManualIncidents(`SELECT id, title, services, start_time, end_time
+		FROM manual_incidents
+		WHERE id = ?`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query manual incident %s: %v", id, err)
+	}
+	if len(incidents) == 0 {
+		return nil, nil
+	}
+	return &incidents[0], nil
+}
+
+// SaveManualIncident replaces the incident and all of its updates in one transaction.
+func (s *SQLiteStorage) SaveManualIncident(incident internal.ManualIncident) error {
+	services, err := json.Marshal(incident.Services)
+	if err != nil {
+		return fmt.Errorf("failed to marshal services: %v", err)
+	}
+	var endTime sql.NullInt64
+	if !incident.Ongoing() {
+		endTime = sql.NullInt64{Int64: incident.EndTime.UnixMilli(), Valid: true}
+	}
+
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	_, err = tx.Exec(`INSERT OR REPLACE INTO manual_incidents (id, title, services, start_time, end_time)
+		VALUES (?, ?, ?, ?, ?)`,
+		incident.ID, incident.Title, string(services), incident.StartTime.UnixMilli(), endTime)
+	if err != nil {
+		return fmt.Errorf("failed to save manual incident: %v", err)
+	}
+	if _, err := tx.Exec(`DELETE FROM incident_updates WHERE incident_id = ?`, incident.ID); err != nil {
+		return fmt.Errorf("failed to replace incident updates: %v", err)
+	}
+	for _, update := range incident.Updates {
+		_, err := tx.Exec(`INSERT INTO incident_updates (incident_id, timestamp, status, message)
+			VALUES (?, ?, ?, ?)`,
+			incident.ID, update.Timestamp.UnixMilli(), string(update.Status), update.Message)
+		if err != nil {
+			return fmt.Errorf("failed to save incident update: %v", err)
+		}
+	}
+	return tx.Commit()
+}
+
+func (s *SQLiteStorage) GetMaintenanceWindows() ([]internal.MaintenanceWindow, error) {
+	rows, err := s.db.Query(`SELECT id, title, services, service_groups, start_time, end_time, schedule, duration
+		FROM maintenance_windows
+		ORDER BY id`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query maintenance windows: %v", err)
+	}
+	defer rows.Close()
+
+	var windows []internal.MaintenanceWindow
+	for rows.Next() {
+		var window internal.MaintenanceWindow
+		var services, groups string
+		var startTime, endTime sql.NullInt64
+		var duration int64
+		err := rows.Scan(&window.ID, &window.Title, &services, &groups, &startTime, &endTime, &window.Schedule, &duration)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read maintenance window: %v", err)
+		}
+
+		if err := json.Unmarshal([]byte(services), &window.Services); err != nil {
+			return nil, fmt.Errorf("failed to read maintenance window: %v", err)
+		}
+		if err := json.Unmarshal([]byte(groups), &window.Groups); err != nil {
+			return nil, fmt.Errorf("failed to read maintenance window: %v", err)
+		}
+		if startTime.Valid {
+			window.Start = time.UnixMilli(startTime.Int64)
+		}
+		if endTime.Valid {
+			window.End = time.UnixMilli(endTime.Int64)
+		}
+		window.Duration = time.Duration(duration) * time.Millisecond
+		windows = append(windows, window)
+	}
+	return windows, rows.Err()
+}
+
+func (s *SQLiteStorage) SaveMaintenanceWindow(window internal.MaintenanceWindow) error {
+	services, err := json.Marshal(window.Services)
+	if err != nil {
+		return fmt.Errorf("failed to marshal services: %v", err)
+	}
+	groups, err := json.Marshal(window.Groups)
+	if err != nil {
+		return fmt.Errorf("failed to marshal groups: %v", err)
+	}
+	var startTime, endTime sql.NullInt64
+	if !window.Recurring() {
+		startTime = sql.NullInt64{Int64: window.Start.UnixMilli(), Valid: true}
+		endTime = sql.NullInt64{Int64: window.End.UnixMilli(), Valid: true}
+	}
+
+	_, err = s.db.Exec(`INSERT OR REPLACE INTO maintenance_windows
+		(id, title, services, service_groups, start_time, end_time, schedule, duration)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)`,
+		window.ID, window.Title, string(services), string(groups), startTime, endTime,
+		window.Schedule, window.Duration.Milliseconds())
+	if err != nil {
+		return fmt.Errorf("failed to save maintenance window: %v", err)
+	}
+	return nil
+}
+
+func (s *SQLiteStorage) DeleteMaintenanceWindow(id string) error {
+	if _, err := s.db.Exec(`DELETE FROM maintenance_windows WHERE id = ?`, id); err != nil {
+		return fmt.Errorf("failed to delete maintenance window: %v", err)
+	}
+	return nil
+}
+
+// internals
+func (s *SQLiteStorage) migrate() error {
+	if _, err := s.db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (version INTEGER NOT NULL)`); err != nil {
+		return fmt.Errorf("failed to create schema_migrations: %v", err)
+	}
+
+	var version int
+	if err := s.db.QueryRow(`SELECT COALESCE(MAX(version), 0) FROM schema_migrations`).Scan(&version); err != nil {
+		return fmt.Errorf("failed to read schema version: %v", err)
+	}
+
+	for i := version; i < len(sqliteMigrations); i++ {
+		tx, err := s.db.Begin()
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(sqliteMigrations[i]); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to apply migration %d: %v", i+1, err)
+		}
+		if _, err := tx.Exec(`INSERT INTO schema_migrations (version) VALUES (?)`, i+1); err != nil {
+			tx.Rollback()
+			return fmt.Errorf("failed to record migration %d: %v", i+1, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *SQLiteStorage) query(query string, args ...interface{}) ([]internal.Status, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query SQLite: %v", err)
+	}
+	defer rows.Close()
+
+	var statuses []internal.Status
+	for rows.Next() {
+		var status internal.Status
+		var timestamp int64
+		var components, certificate sql.NullString
+		err := rows.Scan(&status.Service, &timestamp, &status.Status, &status.Derived, &status.Latency,
+			&status.Assertion, &status.Message, &components, &certificate)
+		if err != nil {
+			return nil, err
+		}
+
+		status.Timestamp = time.UnixMilli(timestamp)
+		if components.Valid {
+			if err := json.Unmarshal([]byte(components.String), &status.Components); err != nil {
+				return nil, err
+			}
+		}
+		if certificate.Valid {
+			status.Certificate = &internal.CertificateInfo{}
+			if err := json.Unmarshal([]byte(certificate.String), status.Certificate); err != nil {
+				return nil, err
+			}
+		}
+		statuses = append(statuses, status)
+	}
+	return statuses, rows.Err()
+}
+
+func (s *SQLiteStorage) queryIncidents(query string, args ...interface{}) ([]internal.Incident, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var incidents []internal.Incident
+	for rows.Next() {
+		var incident internal.Incident
+		var startTime int64
+		var endTime sql.NullInt64
+		if err := rows.Scan(&incident.ID, &incident.Service, &startTime, &endTime, &incident.Cause); err != nil {
+			return nil, err
+		}
+
+		incident.StartTime = time.UnixMilli(startTime)
+		if endTime.Valid {
+			incident.EndTime = time.UnixMilli(endTime.Int64)
+		}
+		incidents = append(incidents, incident)
+	}
+	return incidents, rows.Err()
+}
+
+// queryManualIncidents reads the incidents selected by query and then their
+// updates. The rows are closed before the updates are read, since the
+// database has a single connection.
+func (s *SQLiteStorage) queryManualIncidents(query string, args ...interface{}) ([]internal.ManualIncident, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var incidents []internal.ManualIncident
+	for rows.Next() {
+		var incident internal.ManualIncident
+		var services string
+		var startTime int64
+		var endTime sql.NullInt64
+		if err := rows.Scan(&incident.ID, &incident.Title, &services, &startTime, &endTime); err != nil {
+			return nil, err
+		}
+
+		if err := json.Unmarshal([]byte(services), &incident.Services); err != nil {
+			return nil, err
+		}
+		incident.StartTime = time.UnixMilli(startTime)
+		if endTime.Valid {
+			incident.EndTime = time.UnixMilli(endTime.Int64)
+		}
+		incidents = append(incidents, incident)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+
+	for i := range incidents {
+		if incidents[i].Updates, err = s.queryIncidentUpdates(incidents[i].ID); err != nil {
+			return nil, err
+		}
+	}
+	return incidents, nil
+}
+
+func (s *SQLiteStorage) queryIncidentUpdates(id string) ([]internal.IncidentUpdate, error) {
+	rows, err := s.db.Query(`SELECT timestamp, status, message
+		FROM incident_updates
+		WHERE incident_id = ?
+		ORDER BY timestamp, rowid`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var updates []internal.IncidentUpdate
+	for rows.Next() {
+		var update internal.IncidentUpdate
+		var timestamp int64
+		if err := rows.Scan(&timestamp, &update.Status, &update.Message); err != nil {
+			return nil, err
+		}
+		update.Timestamp = time.UnixMilli(timestamp)
+		updates = append(updates, update)
+	}
+	return updates, rows.Err()
+}
+
+// marshalNullable encodes v as JSON, or NULL when present is false.
+func marshalNullable(v interface{}, present bool) (sql.NullString, error) {
+	if !present {
+		return sql.NullString{}, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return sql.NullString{}, err
+	}
+	return sql.NullString{String: string(data), Valid: true}, nil
+}
@@ -1,9 +1,98 @@
 package storage
 
-import "int-status/internal"
+import (
+	"errors"
+	"int-status/internal"
+	"time"
+)
+
+// DefaultPageSize is used when HistoryQuery.Limit is not set.
+const DefaultPageSize = 100
+
+// ErrInvalidCursor is returned for a HistoryQuery.Cursor that no backend could
+// have produced, e.g. one that was truncated or edited.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// HistoryQuery selects the statuses of a service recorded within [From, To].
+// Pages walk backwards in time: the first page holds the newest statuses and
+// each NextCursor continues with older ones.
+// @field From   The inclusive start of the range.
+// @field To     The inclusive end of the range.
+// @field Limit  The maximum number of statuses per page. Defaults to DefaultPageSize.
+// @field Cursor An opaque token from a previous HistoryPage.NextCursor.
+type HistoryQuery struct {
+	From   time.Time
+	To     time.Time
+	Limit  int
+	Cursor string
+}
+
+// HistoryPage is one page of a HistoryQuery.
+// @field Statuses   The statuses of the page, oldest first.
+// @field NextCursor The cursor of the next (older) page; empty on the last page.
+type HistoryPage struct {
+	Statuses   []internal.Status
+	NextCursor string
+}
 
 type Storage interface {
-	GetDailyHistory(service string) ([]internal.Status, error)
-	GetDailyIncidents(service string) ([]internal.Incident, error)
+	GetHistory(service string, query HistoryQuery) (HistoryPage, error)
 	UpdateHistory(statuses []internal.Status) error
+	// GetUptime sums how long a service was up and down within [from, to] for
+	// every from in froms, reading the statuses only once, and returns the sums
+	// in the same order. Each status covers the time until the next one, but
+	// at most maxGap.
+	GetUptime(service string, froms []time.Time, to time.Time, maxGap time.Duration) ([]internal.Uptime, error)
+
+	// GetIncidents returns the incidents of a service that overlap [from, to],
+	// oldest first. Ongoing incidents overlap every range after their start.
+	GetIncidents(service string, from, to time.Time) ([]internal.Incident, error)
+	// GetOpenIncident returns the ongoing incident of a service, or nil.
+	GetOpenIncident(service string) (*internal.Incident, error)
+	// SaveIncident creates the incident, or replaces the one with the same ID.
+	SaveIncident(incident internal.Incident) error
+
+	// GetManualIncidents returns the manual incidents that overlap [from, to],
+	// oldest first.
+	GetManualIncidents(from, to time.Time) ([]internal.ManualIncident, error)
+	// GetManualIncident returns the manual incident with the ID, or nil.
+	GetManualIncident(id string) (*internal.ManualIncident, error)
+	// SaveManualIncident creates the manual incident, or replaces the one with
+	// the same ID.
+	SaveManualIncident(incident internal.ManualIncident) error
+
+	// GetMaintenanceWindows returns the maintenance windows created through
+	// the API. Windows of config.yaml are never stored.
+	GetMaintenanceWindows() ([]internal.MaintenanceWindow, error)
+	// SaveMaintenanceWindow creates the window, or replaces the one with the
+	// same ID.
+	SaveMaintenanceWindow(window internal.MaintenanceWindow) error
+	// DeleteMaintenanceWindow deletes the window with the ID, if there is one.
+	DeleteMaintenanceWindow(id string) error
+}
+
+// GetAllHistory follows every page of a query and returns all statuses in
+// the range, oldest first.
+func GetAllHistory(s Storage, service string, from, to time.Time) ([]internal.Status, error) {
+	var pages [][]internal.Status
+	total := 0
+	query := HistoryQuery{From: from, To: to}
+	for {
+		page, err := s.GetHistory(service, query)
+		if err != nil {
+			return nil, err
+		}
+		pages = append(pages, page.Statuses)
+		total += len(page.Statuses)
+		if page.NextCursor == "" {
+			break
+		}
+		query.Cursor = page.NextCursor
+	}
+
+	statuses := make([]internal.Status, 0, total)
+	for i := len(pages) - 1; i >= 0; i-- {
+		statuses = append(statuses, pages[i]...)
+	}
+	return statuses, nil
 }
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"int-status/internal"
+	"time"
+)
+
+// uptimeCounter sums the time covered by the statuses of a service within
+// [from, to] for several values of from at once, so that every window is
+// filled from a single pass. Statuses must be added oldest first. Each one
+// covers the time until the next, but at most maxGap, so that periods
+// without checks, e.g. while TinyPing was stopped, count for nothing.
+type uptimeCounter struct {
+	froms  []time.Time
+	to     time.Time
+	maxGap time.Duration
+	last   *internal.Status
+	uptime []internal.Uptime
+}
+
+func newUptimeCounter(froms []time.Time, to time.Time, maxGap time.Duration) *uptimeCounter {
+	return &uptimeCounter{froms: froms, to: to, maxGap: maxGap, uptime: make([]internal.Uptime, len(froms))}
+}
+
+// queryStart is where a query for the counter must start: the last status
+// before the earliest from may still cover part of its range.
+func (c *uptimeCounter) queryStart() time.Time {
+	start := c.to
+	for _, from := range c.froms {
+		if from.Before(start) {
+			start = from
+		}
+	}
+	return start.Add(-c.maxGap)
+}
+
+func (c *uptimeCounter) add(status internal.Status) {
+	if c.last != nil {
+		c.count(*c.last, status.Timestamp)
+	}
+	c.last = &status
+}
+
+// result counts the last status up to to and returns the sums, in the order
+// of froms.
+func (c *uptimeCounter) result() []internal.Uptime {
+	if c.last != nil {
+		c.count(*c.last, c.to)
+		c.last = nil
+	}
+	return c.uptime
+}
+
+func (c *uptimeCounter) count(status internal.Status, next time.Time) {
+	end := next
+	if limit := status.Timestamp.Add(c.maxGap); end.After(limit) {
+		end = limit
+	}
+	if end.After(c.to) {
+		end = c.to
+	}
+	for i, from := range c.froms {
+		start := status.Timestamp
+		if start.Before(from) {
+			start = from
+		}
+		if end.After(start) {
+			c.uptime[i].Add(status, end.Sub(start))
+		}
+	}
+}